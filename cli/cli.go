@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+// Command is a subcommand of the procfile command line interface. It returns the exit code of the command line
+// interface.
+type Command func(args []string, stdout io.Writer, stderr io.Writer, signals <-chan os.Signal) (int, error)
+
+// Commands are the subcommands of the procfile command line interface, keyed by name.
+var Commands = map[string]Command{
+	"export": Export,
+	"start":  Start,
+}
+
+// Run runs the subcommand named by the first of args with the remaining ones and returns its exit code.
+func Run(args []string, stdout io.Writer, stderr io.Writer, signals <-chan os.Signal) int {
+	var names []string
+	for n := range Commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if len(args) == 0 {
+		_, _ = fmt.Fprintf(stderr, "usage: procfile <command> [arguments]\n\ncommands: %v\n", names)
+		return 2
+	}
+
+	c, ok := Commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown command %s\n\ncommands: %v\n", args[0], names)
+		return 2
+	}
+
+	code, err := c(args[1:], stdout, stderr, signals)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// loadProcfile reads the Procfile sources of the application at path and substitutes their placeholders, like detection
+// does, and returns the process types for the given types, or all if none are given.
+func loadProcfile(path string, types []string, logger bard.Logger) (procfile.Procfile, error) {
+	bindings, err := libcnb.NewBindingsForLaunch()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read bindings\n%w", err)
+	}
+
+	sources, err := procfile.NewSources(path, bindings)
+	if err != nil {
+		return nil, err
+	}
+	if sherpa.ResolveBool("BP_PROCFILE_SUBSTITUTE") {
+		if sources, err = procfile.NewVariables(bindings).SubstituteSources(sources, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	p, _ := procfile.Merge(sources...)
+	p, _ = procfile.NewFilterFromEnvironment().Apply(p)
+	if len(p) == 0 {
+		return nil, fmt.Errorf("no process types found in %s", path)
+	}
+
+	for _, t := range types {
+		if _, ok := p[t]; !ok {
+			return nil, fmt.Errorf("unknown process type %s", t)
+		}
+	}
+	p, _ = procfile.Filter{Include: types}.Apply(p)
+
+	return p, nil
+}
+
+// loadProcesses reads the Procfile sources of the application at path, like detection does, and returns the processes
+// for the given types, or all if none are given, sorted by type, as the buildpack contributes them for a stack with a
+// shell and a launcher interpolating direct processes, which the supervisor does in its place.
+func loadProcesses(path string, direct bool, types []string, logger bard.Logger) ([]libcnb.Process, error) {
+	p, err := loadProcfile(path, types, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := procfile.NewPipeline(true, true, logger)
+	pipeline.Execution.Direct = direct
+	return pipeline.Processes(p)
+}
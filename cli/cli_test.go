@@ -0,0 +1,49 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/cli"
+)
+
+func testCLI(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stdout, stderr *bytes.Buffer
+	)
+
+	it.Before(func() {
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	})
+
+	it("prints usage without command", func() {
+		Expect(cli.Run(nil, stdout, stderr, nil)).To(Equal(2))
+		Expect(stderr.String()).To(ContainSubstring("usage: procfile <command> [arguments]"))
+	})
+
+	it("fails with an unknown command", func() {
+		Expect(cli.Run([]string{"test-command"}, stdout, stderr, nil)).To(Equal(2))
+		Expect(stderr.String()).To(ContainSubstring("unknown command test-command"))
+	})
+}
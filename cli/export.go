@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+// Export renders the given process types of an application, or all of them, as deployment files of a format. The files
+// are written to an output directory, or to stdout if none is given.
+func Export(args []string, stdout io.Writer, stderr io.Writer, _ <-chan os.Signal) (int, error) {
+	var formats []string
+	for f := range export.Formats {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "usage: procfile export -format <format> [flags] [process-type...]")
+		flags.PrintDefaults()
+	}
+
+	path := flags.String("path", ".", "the application directory containing the Procfile")
+	format := flags.String("format", "", fmt.Sprintf("the format of the deployment files, one of %v", formats))
+	image := flags.String("image", "", "the reference of the application image, required")
+	name := flags.String("name", "", "the name of the application, the name of the application directory if empty")
+	output := flags.String("output", "", "the directory to write the deployment files to, stdout if empty")
+	workingDirectory := flags.String("working-directory", "", "the working directory of the processes, the image's if empty")
+	restart := flags.String("restart", export.RestartAlways, fmt.Sprintf("the restart policy of processes that are not scheduled, one of %v", export.RestartPolicies))
+	environment := map[string]string{}
+	flags.Func("env", "an environment variable NAME=value of the processes, may be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("expected NAME=value")
+		}
+		environment[k] = v
+		return nil
+	})
+	direct := flags.Bool("direct", sherpa.ResolveBool("BP_DIRECT_PROCESS"), "start processes directly instead of with a shell")
+	if err := flags.Parse(args); err != nil {
+		return 2, nil
+	}
+
+	f, ok := export.Formats[*format]
+	if !ok {
+		return 2, fmt.Errorf("unknown format %q, must be one of %v", *format, formats)
+	}
+	if !slices.Contains(export.RestartPolicies, *restart) {
+		return 2, fmt.Errorf("unknown restart policy %q, must be one of %v", *restart, export.RestartPolicies)
+	}
+
+	abs, err := filepath.Abs(*path)
+	if err != nil {
+		return 1, fmt.Errorf("unable to resolve %s\n%w", *path, err)
+	}
+	if *name == "" {
+		*name = filepath.Base(abs)
+	}
+
+	p, err := loadProcfile(*path, flags.Args(), bard.NewLogger(stderr))
+	if err != nil {
+		return 1, err
+	}
+
+	application, err := export.NewApplication(*name, *image, p, procfile.Execution{Direct: *direct, Shell: true})
+	if err != nil {
+		return 1, err
+	}
+	application.WorkingDirectory = *workingDirectory
+	application.Environment = environment
+	application.Restart = *restart
+
+	files, err := f(application)
+	if err != nil {
+		return 1, err
+	}
+
+	for _, file := range files {
+		if *output == "" {
+			if len(files) > 1 {
+				_, _ = fmt.Fprintf(stdout, "# %s\n", file.Name)
+			}
+			_, _ = stdout.Write(file.Content)
+			continue
+		}
+
+		if err := os.MkdirAll(*output, 0755); err != nil {
+			return 1, fmt.Errorf("unable to create %s\n%w", *output, err)
+		}
+		if err := os.WriteFile(filepath.Join(*output, file.Name), file.Content, 0644); err != nil {
+			return 1, fmt.Errorf("unable to write %s\n%w", file.Name, err)
+		}
+		_, _ = fmt.Fprintf(stdout, "Wrote %s\n", filepath.Join(*output, file.Name))
+	}
+
+	return 0, nil
+}
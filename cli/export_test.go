@@ -0,0 +1,104 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/cli"
+)
+
+func testExport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path           string
+		stdout, stderr *bytes.Buffer
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "my-app")
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+
+		Expect(os.MkdirAll(path, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: web-command --port 8080\nworker: worker-command"), 0644)).To(Succeed())
+	})
+
+	it("writes the deployment files of the selected process types to stdout", func() {
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "kubernetes", "-image", "registry/my-app:1", "web"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(ContainSubstring("name: my-app-web\n"))
+		Expect(stdout.String()).To(ContainSubstring("- /cnb/process/web\n"))
+		Expect(stdout.String()).To(ContainSubstring("containerPort: 8080\n"))
+		Expect(stdout.String()).NotTo(ContainSubstring("worker"))
+	})
+
+	it("writes the deployment files to an output directory", func() {
+		output := filepath.Join(t.TempDir(), "deploy")
+
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "kubernetes", "-image", "registry/my-app:1", "-name", "app", "-output", output}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(Equal("Wrote " + filepath.Join(output, "kubernetes.yaml") + "\n"))
+		Expect(os.ReadFile(filepath.Join(output, "kubernetes.yaml"))).To(And(
+			ContainSubstring("name: app-web\n"),
+			ContainSubstring("name: app-worker\n"),
+		))
+	})
+
+	it("writes systemd units with environment, working directory and restart policy", func() {
+		output := t.TempDir()
+
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "systemd", "-image", "registry/my-app:1", "-env", "RAILS_ENV=production", "-env", "EMPTY=",
+			"-working-directory", "/srv/my-app", "-restart", "on-failure", "-output", output}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(os.ReadFile(filepath.Join(output, "my-app-web.service"))).To(And(
+			ContainSubstring("ExecStart=/usr/bin/podman run --rm --replace --name my-app-web --entrypoint /cnb/process/web --workdir /srv/my-app "+
+				"--env EMPTY= --env RAILS_ENV=production --publish 8080:8080 registry/my-app:1\n"),
+			ContainSubstring("Restart=on-failure\n"),
+		))
+		Expect(filepath.Join(output, "my-app-worker.service")).To(BeARegularFile())
+		Expect(filepath.Join(output, "my-app.target")).To(BeARegularFile())
+	})
+
+	it("writes several files to stdout with their names", func() {
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "systemd", "-image", "registry/my-app:1"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(HavePrefix("# my-app-web.service\n[Unit]\n"))
+		Expect(stdout.String()).To(ContainSubstring("# my-app.target\n"))
+	})
+
+	it("fails with an unknown restart policy", func() {
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "compose", "-image", "registry/my-app:1", "-restart", "sometimes"}, stdout, stderr, nil)).To(Equal(2))
+		Expect(stderr.String()).To(ContainSubstring(`unknown restart policy "sometimes"`))
+	})
+
+	it("fails with an unknown format", func() {
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "helm", "-image", "registry/my-app:1"}, stdout, stderr, nil)).To(Equal(2))
+		Expect(stderr.String()).To(ContainSubstring(`unknown format "helm"`))
+	})
+
+	it("fails without image", func() {
+		Expect(cli.Run([]string{"export", "-path", path, "-format", "kubernetes"}, stdout, stderr, nil)).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("an image is required"))
+	})
+}
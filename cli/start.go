@@ -0,0 +1,72 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/supervisor"
+)
+
+// Start starts the given process types of an application, or all of them, locally and concurrently, like foreman or
+// honcho. Every process is assigned a PORT, starting from a base port and incremented by 100 per process type.
+func Start(args []string, stdout io.Writer, stderr io.Writer, signals <-chan os.Signal) (int, error) {
+	flags := flag.NewFlagSet("start", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "usage: procfile start [flags] [process-type...]")
+		flags.PrintDefaults()
+	}
+
+	path := flags.String("path", ".", "the application directory containing the Procfile")
+	port := flags.Int("port", 5000, "the PORT of the first process type, incremented by 100 for each further one")
+	direct := flags.Bool("direct", sherpa.ResolveBool("BP_DIRECT_PROCESS"), "start processes directly instead of with a shell")
+	noColor := flags.Bool("no-color", false, "do not color the output prefixes")
+	if err := flags.Parse(args); err != nil {
+		return 2, nil
+	}
+
+	processes, err := loadProcesses(*path, *direct, flags.Args(), bard.NewLogger(stderr))
+	if err != nil {
+		return 1, err
+	}
+
+	s := supervisor.Supervisor{
+		Processes:   processes,
+		Shell:       supervisor.DefaultShell,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		Grace:       supervisor.DefaultGrace,
+		Timeout:     supervisor.DefaultTimeout,
+		Environment: map[string][]string{},
+		Colors:      !*noColor,
+	}
+	for i, p := range processes {
+		s.Environment[p.Type] = []string{fmt.Sprintf("PORT=%d", *port+100*i)}
+		if p.WorkingDirectory == "" {
+			s.Processes[i].WorkingDirectory = *path
+		}
+	}
+
+	return s.Run(signals)
+}
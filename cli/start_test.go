@@ -0,0 +1,102 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/cli"
+)
+
+func testStart(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path           string
+		stdout, stderr *bytes.Buffer
+	)
+
+	it.Before(func() {
+		path = t.TempDir()
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte(`web: echo web on $PORT
+worker: echo worker on $PORT in $(basename $(pwd))
+clock: echo clock`), 0644)).To(Succeed())
+	})
+
+	it("starts the selected process types with a PORT each", func() {
+		Expect(cli.Run([]string{"start", "-path", path, "-port", "6000", "-no-color", "web", "worker"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(ContainSubstring("web    | web on 6000\n"))
+		Expect(stdout.String()).To(ContainSubstring("worker | worker on 6100 in " + filepath.Base(path) + "\n"))
+		Expect(stdout.String()).NotTo(ContainSubstring("clock"))
+	})
+
+	it("starts processes directly", func() {
+		Expect(cli.Run([]string{"start", "-path", path, "-direct", "-no-color", "clock"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(Equal("clock | clock\n"))
+	})
+
+	it("interpolates environment variables of processes started directly, like the launcher", func() {
+		Expect(cli.Run([]string{"start", "-path", path, "-direct", "-no-color", "web"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(Equal("web | web on 5000\n"))
+	})
+
+	it("prefixes commands with exec and substitutes placeholders, like the buildpack", func() {
+		t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+		t.Setenv("BP_GREETING", "hello")
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte(`web: echo ${BP_GREETING} from $0`), 0644)).To(Succeed())
+
+		Expect(cli.Run([]string{"start", "-path", path, "-no-color"}, stdout, stderr, nil)).To(Equal(0))
+
+		Expect(stdout.String()).To(Equal("web | hello from web\n"))
+		Expect(stderr.String()).To(ContainSubstring("Prefixing commands of process types web with exec"))
+	})
+
+	it("stops all processes when interrupted", func() {
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: sleep 60\nworker: sleep 60"), 0644)).To(Succeed())
+
+		signals := make(chan os.Signal, 1)
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			signals <- syscall.SIGINT
+		}()
+
+		Expect(cli.Run([]string{"start", "-path", path}, stdout, stderr, signals)).To(Equal(128 + int(syscall.SIGINT)))
+	})
+
+	it("fails with an unknown process type", func() {
+		Expect(cli.Run([]string{"start", "-path", path, "release"}, stdout, stderr, nil)).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("unknown process type release"))
+	})
+
+	it("fails without Procfile", func() {
+		Expect(cli.Run([]string{"start", "-path", t.TempDir()}, stdout, stderr, nil)).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("no process types found"))
+	})
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/helper"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func main() {
+	sherpa.Execute(func() error {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("unable to find executable\n%w", err)
+		}
+
+		helpers, err := helper.NewPreStartHelpers(filepath.Join(filepath.Dir(exe), "..", procfile.HooksFile))
+		if err != nil {
+			return err
+		}
+		helpers[procfile.ProfileHelper] = helper.Profile{}
+
+		return sherpa.Helpers(helpers)
+	})
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	ComposeFile             = "docker-compose.yml" // ComposeFile is the name of the Docker Compose file
+	ComposeScheduledProfile = "scheduled"          // ComposeScheduledProfile is the profile of scheduled services
+)
+
+var (
+	// quantity matches a resource quantity and its suffix.
+	quantity = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)(m|k|M|G|T|Ki|Mi|Gi|Ti)?$`)
+
+	// quantitySuffixes are the multipliers of the suffixes of resource quantities.
+	quantitySuffixes = map[string]float64{
+		"": 1, "m": 0.001,
+		"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12,
+		"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40,
+	}
+)
+
+// Compose renders an application as a Docker Compose file with a service per process type. Services start the process
+// types with their launcher entrypoints, with the environment, working directory and restart policy of the application
+// and the port, command health check and formation of the process types, if known. Scheduled process types are
+// assigned the scheduled profile and not restarted, so that a scheduler can run them with docker compose run.
+func Compose(application Application) ([]File, error) {
+	if application.Image == "" {
+		return nil, fmt.Errorf("an image is required")
+	}
+
+	c := composeFile{Services: map[string]composeService{}}
+	for _, p := range application.Processes {
+		s := composeService{
+			Image:       application.Image,
+			Entrypoint:  []string{ProcessPath + p.Type},
+			WorkingDir:  application.WorkingDirectory,
+			Environment: application.Environment,
+			Restart:     application.Restart,
+		}
+
+		if p.Schedule != "" {
+			s.Profiles = []string{ComposeScheduledProfile}
+			s.Restart = RestartNo
+		}
+
+		if p.Port != 0 {
+			if p.Formation != nil && p.Formation.Replicas != nil && *p.Formation.Replicas > 1 {
+				s.Ports = []string{strconv.Itoa(p.Port)}
+			} else {
+				s.Ports = []string{fmt.Sprintf("%d:%d", p.Port, p.Port)}
+			}
+		}
+
+		if h := p.HealthCheck; h != nil && h.Path != "" {
+			return nil, fmt.Errorf("unable to render process type %s, Docker Compose does not support the HTTP health check path %s, declare a health check command instead",
+				p.Type, h.Path)
+		} else if h != nil && h.Command != "" {
+			s.HealthCheck = &composeHealthCheck{
+				Test:     append([]string{"CMD"}, launcherCommand(h.Command)...),
+				Interval: h.Interval,
+				Timeout:  h.Timeout,
+			}
+		}
+
+		if f := p.Formation; f != nil {
+			s.Deploy = &composeDeploy{Replicas: f.Replicas}
+
+			if f.CPU != "" || f.Memory != "" {
+				s.Deploy.Resources = &composeResources{Limits: map[string]string{}}
+				if f.CPU != "" {
+					v, err := parseQuantity(f.CPU)
+					if err != nil {
+						return nil, fmt.Errorf("unable to render process type %s\n%w", p.Type, err)
+					}
+					s.Deploy.Resources.Limits["cpus"] = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+				if f.Memory != "" {
+					v, err := parseQuantity(f.Memory)
+					if err != nil {
+						return nil, fmt.Errorf("unable to render process type %s\n%w", p.Type, err)
+					}
+					s.Deploy.Resources.Limits["memory"] = strconv.FormatInt(int64(v), 10)
+				}
+			}
+		}
+
+		c.Services[p.Type] = s
+	}
+
+	b := &bytes.Buffer{}
+	e := yaml.NewEncoder(b)
+	e.SetIndent(2)
+	if err := e.Encode(c); err != nil {
+		return nil, fmt.Errorf("unable to encode %s\n%w", ComposeFile, err)
+	}
+	if err := e.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encode %s\n%w", ComposeFile, err)
+	}
+
+	return []File{{Name: ComposeFile, Content: b.Bytes()}}, nil
+}
+
+// parseQuantity returns the value of a resource quantity such as 500m or 512Mi.
+func parseQuantity(s string) (float64, error) {
+	m := quantity.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid quantity %s", s)
+	}
+
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse quantity %s\n%w", s, err)
+	}
+
+	return v * quantitySuffixes[m[2]], nil
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image       string              `yaml:"image"`
+	Entrypoint  []string            `yaml:"entrypoint"`
+	Profiles    []string            `yaml:"profiles,omitempty"`
+	WorkingDir  string              `yaml:"working_dir,omitempty"`
+	Environment map[string]string   `yaml:"environment,omitempty"`
+	Restart     string              `yaml:"restart,omitempty"`
+	Ports       []string            `yaml:"ports,omitempty"`
+	HealthCheck *composeHealthCheck `yaml:"healthcheck,omitempty"`
+	Deploy      *composeDeploy      `yaml:"deploy,omitempty"`
+}
+
+type composeHealthCheck struct {
+	Test     []string `yaml:"test"`
+	Interval string   `yaml:"interval,omitempty"`
+	Timeout  string   `yaml:"timeout,omitempty"`
+}
+
+type composeDeploy struct {
+	Replicas  *int              `yaml:"replicas,omitempty"`
+	Resources *composeResources `yaml:"resources,omitempty"`
+}
+
+type composeResources struct {
+	Limits map[string]string `yaml:"limits,omitempty"`
+}
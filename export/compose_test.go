@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testCompose(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	render := func(p procfile.Procfile, configure func(*export.Application)) []export.File {
+		t.Helper()
+
+		application, err := export.NewApplication("my-app", "registry.example.com/my-app:1.0.0", p, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+		if configure != nil {
+			configure(&application)
+		}
+
+		files, err := export.Compose(application)
+		Expect(err).NotTo(HaveOccurred())
+		return files
+	}
+
+	it("renders a service per process type", func() {
+		expectGolden(t, "compose/services", render(procfile.Procfile{
+			"web":    "bundle exec rails server -p ${PORT:-3000}",
+			"worker": "bundle exec sidekiq",
+		}, nil))
+	})
+
+	it("renders environment, working directory, restart policy and attributes", func() {
+		expectGolden(t, "compose/attributes", render(procfile.Procfile{
+			"web": map[string]interface{}{
+				"command":   "web-command",
+				"port":      8080,
+				"formation": map[string]interface{}{"replicas": 3, "cpu": "500m", "memory": "1Gi"},
+			},
+			"worker": map[string]interface{}{
+				"command":      "worker-command",
+				"health-check": map[string]interface{}{"command": "test -f /tmp/healthy", "interval": "30s", "timeout": "5s"},
+				"formation":    map[string]interface{}{"cpu": "2", "memory": "512M"},
+			},
+			"clock": map[string]interface{}{"command": "clock-command", "schedule": "@hourly"},
+		}, func(a *export.Application) {
+			a.WorkingDirectory = "/workspace/app"
+			a.Environment = map[string]string{"RAILS_ENV": "production", "LANG": "C.UTF-8"}
+			a.Restart = export.RestartOnFailure
+		}))
+	})
+
+	it("returns error for HTTP health checks", func() {
+		application, err := export.NewApplication("app", "app", procfile.Procfile{
+			"web": map[string]interface{}{"command": "web-command", "port": 8080, "health-check": map[string]interface{}{"path": "/health"}},
+		}, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = export.Compose(application)
+		Expect(err).To(MatchError("unable to render process type web, Docker Compose does not support the HTTP health check path /health, declare a health check command instead"))
+	})
+
+	it("returns error without image", func() {
+		_, err := export.Compose(export.Application{Name: "my-app"})
+		Expect(err).To(MatchError("an image is required"))
+	})
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/mattn/go-shellwords"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+const (
+	ProcessPath = "/cnb/process/"           // ProcessPath is the path of the launcher entrypoint of a process type in an image
+	Launcher    = "/cnb/lifecycle/launcher" // Launcher is the path of the launcher in an image, running commands in the process environment
+
+	RestartAlways    = "always"     // RestartAlways restarts processes whenever they exit
+	RestartOnFailure = "on-failure" // RestartOnFailure restarts processes when they exit unsuccessfully
+	RestartNo        = "no"         // RestartNo does not restart processes
+)
+
+// RestartPolicies are the supported restart policies of processes.
+var RestartPolicies = []string{RestartAlways, RestartOnFailure, RestartNo}
+
+// File is a file written by a format.
+type File struct {
+
+	// Name is the name of the file.
+	Name string
+
+	// Content is the content of the file.
+	Content []byte
+}
+
+// Format renders an application as deployment files.
+type Format func(application Application) ([]File, error)
+
+// Formats are the formats an application can be exported to, keyed by name.
+var Formats = map[string]Format{
+	"compose":    Compose,
+	"kubernetes": Kubernetes,
+	"systemd":    Systemd,
+}
+
+// Process is a process type of an application, with the attributes declared for it in Procfile.toml.
+type Process struct {
+	libcnb.Process
+
+	// Port is the declared or inferred port of the process type, 0 if unknown.
+	Port int
+
+	// HealthCheck is the health check of the process type, if declared.
+	HealthCheck *procfile.HealthCheck
+
+	// Formation is the formation of the process type, if declared.
+	Formation *procfile.Formation
+
+	// Schedule is the cron schedule of the process type, if declared.
+	Schedule string
+}
+
+// Application is an application image and its process types.
+type Application struct {
+
+	// Name is the name of the application.
+	Name string
+
+	// Image is the reference of the application image.
+	Image string
+
+	// WorkingDirectory is the working directory of the processes, if it differs from the default of the format.
+	WorkingDirectory string
+
+	// Environment is the environment of the processes.
+	Environment map[string]string
+
+	// Restart is the restart policy of the processes that are not scheduled, one of RestartPolicies.
+	Restart string
+
+	// Processes are the process types of the application, sorted by type. Process types declared as pre-start hooks
+	// are not included, the launcher runs them before the others.
+	Processes []Process
+}
+
+// NewApplication creates an Application named name for the process types of p in the image image.
+func NewApplication(name string, image string, p procfile.Procfile, execution procfile.Execution) (Application, error) {
+	processes, err := procfile.NewProcesses(p, execution)
+	if err != nil {
+		return Application{}, err
+	}
+
+	hooks, err := procfile.NewHooks(p, processes)
+	if err != nil {
+		return Application{}, err
+	}
+
+	ports, _, err := procfile.NewPorts(p)
+	if err != nil {
+		return Application{}, err
+	}
+
+	application := Application{Name: name, Image: image, Environment: map[string]string{}, Restart: RestartAlways}
+	for _, proc := range processes {
+		if slices.ContainsFunc(hooks, func(h procfile.Hook) bool { return h.Type == proc.Type }) {
+			continue
+		}
+
+		d, err := procfile.NewDefinition(p[proc.Type])
+		if err != nil {
+			return Application{}, fmt.Errorf("invalid process type %s\n%w", proc.Type, err)
+		}
+
+		application.Processes = append(application.Processes, Process{
+			Process:     proc,
+			Port:        ports[proc.Type],
+			HealthCheck: d.HealthCheck,
+			Formation:   d.Formation,
+			Schedule:    d.Schedule,
+		})
+	}
+
+	sort.Slice(application.Processes, func(i int, j int) bool {
+		return application.Processes[i].Type < application.Processes[j].Type
+	})
+
+	return application, nil
+}
+
+// launcherCommand returns the arguments running command with the launcher. A single simple command without expansions
+// is split into its arguments and executed directly, after --, so that it does not depend on a shell in the image.
+// Other commands are passed as one argument, which the launcher executes with bash.
+func launcherCommand(command string) []string {
+	if _, ok := procfile.NewExecCommand(command); !ok || strings.ContainsAny(command, "$`*?~") {
+		return []string{Launcher, command}
+	}
+
+	p := shellwords.NewParser()
+	args, err := p.Parse(command)
+	if err != nil || p.Position != -1 {
+		return []string{Launcher, command}
+	}
+
+	return append([]string{Launcher, "--"}, args...)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testExport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("creates an application with the attributes of its process types", func() {
+		replicas := 2
+		p := procfile.Procfile{
+			"web": map[string]interface{}{
+				"command":      "web-command --port 8080",
+				"health-check": map[string]interface{}{"path": "/health"},
+				"formation":    map[string]interface{}{"replicas": 2, "memory": "512Mi"},
+			},
+			"clock": map[string]interface{}{"command": "clock-command", "schedule": "@hourly"},
+		}
+
+		Expect(export.NewApplication("app", "registry/app:1", p, procfile.Execution{Shell: true})).To(Equal(export.Application{
+			Name:        "app",
+			Image:       "registry/app:1",
+			Environment: map[string]string{},
+			Restart:     export.RestartAlways,
+			Processes: []export.Process{
+				{
+					Process:  libcnb.Process{Type: "clock", Command: "clock-command"},
+					Schedule: "@hourly",
+				},
+				{
+					Process:     libcnb.Process{Type: "web", Command: "web-command --port 8080"},
+					Port:        8080,
+					HealthCheck: &procfile.HealthCheck{Path: "/health"},
+					Formation:   &procfile.Formation{Replicas: &replicas, Memory: "512Mi"},
+				},
+			},
+		}))
+	})
+
+	it("does not include pre-start hooks", func() {
+		p := procfile.Procfile{
+			"migrate": map[string]interface{}{"command": "migrate-command", "pre-start": map[string]interface{}{}},
+			"web":     "web-command",
+		}
+
+		application, err := export.NewApplication("app", "registry/app:1", p, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(application.Processes).To(ConsistOf(HaveField("Type", "web")))
+	})
+
+	it("returns error for invalid process types", func() {
+		p := procfile.Procfile{"web": map[string]interface{}{"command": "web-command", "port": 0, "unknown": true}}
+
+		_, err := export.NewApplication("app", "registry/app:1", p, procfile.Execution{Shell: true})
+		Expect(err).To(HaveOccurred())
+	})
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestUnit(t *testing.T) {
+	suite := spec.New("export", spec.Report(report.Terminal{}))
+	suite("Compose", testCompose)
+	suite("Export", testExport)
+	suite("Kubernetes", testKubernetes)
+	suite("Systemd", testSystemd)
+	suite.Run(t)
+}
+
+// expectGolden asserts that files match the golden files in testdata/name, or updates them if -update is set.
+func expectGolden(t *testing.T, name string, files []export.File) {
+	t.Helper()
+	Expect := NewWithT(t).Expect
+
+	dir := filepath.Join("testdata", name)
+	if *update {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		for _, f := range files {
+			Expect(os.WriteFile(filepath.Join(dir, f.Name), f.Content, 0644)).To(Succeed())
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(entries).To(HaveLen(len(files)))
+
+	for _, f := range files {
+		golden, err := os.ReadFile(filepath.Join(dir, f.Name))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(f.Content)).To(Equal(string(golden)), "%s does not match its golden file, run the tests with -update", f.Name)
+	}
+}
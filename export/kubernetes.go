@@ -0,0 +1,325 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+// KubernetesFile is the name of the file containing the Kubernetes manifests.
+const KubernetesFile = "kubernetes.yaml"
+
+var (
+	// invalidName matches the characters not allowed in Kubernetes object and container names.
+	invalidName = regexp.MustCompile(`[^a-z0-9-]+`)
+
+	// timeZone matches the time zone prefix of a cron schedule.
+	timeZone = regexp.MustCompile(`^(CRON_)?TZ=(\S+)\s+`)
+)
+
+// Kubernetes renders an application as a Deployment per process type, or a CronJob per scheduled process type. The
+// containers start the process types with their launcher entrypoints and declare the port, health check and resources
+// of the process types, if known.
+func Kubernetes(application Application) ([]File, error) {
+	if application.Image == "" {
+		return nil, fmt.Errorf("an image is required")
+	}
+
+	b := &bytes.Buffer{}
+	e := yaml.NewEncoder(b)
+	e.SetIndent(2)
+
+	var (
+		names      = map[string]string{}
+		components = map[string]string{}
+	)
+	for _, p := range application.Processes {
+		c, err := newKubernetesContainer(application, p)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render process type %s\n%w", p.Type, err)
+		}
+
+		// Names are lowercased and stripped of invalid characters, so distinct process types might share them
+		name := kubernetesName(application.Name + "-" + p.Type)
+		if t, ok := names[name]; ok {
+			return nil, fmt.Errorf("unable to render process type %s, its Kubernetes name %s is the name of process type %s",
+				p.Type, name, t)
+		}
+		if t, ok := components[c.Name]; ok {
+			return nil, fmt.Errorf("unable to render process type %s, its Kubernetes component %s is the component of process type %s",
+				p.Type, c.Name, t)
+		}
+		names[name], components[c.Name] = p.Type, p.Type
+
+		metadata := kubernetesMetadata{
+			Name: name,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      kubernetesName(application.Name),
+				"app.kubernetes.io/component": kubernetesName(p.Type),
+			},
+		}
+
+		var object interface{}
+		if p.Schedule != "" {
+			schedule, zone := p.Schedule, ""
+			if m := timeZone.FindStringSubmatch(schedule); m != nil {
+				schedule, zone = strings.TrimPrefix(schedule, m[0]), m[2]
+			}
+
+			object = kubernetesCronJob{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Metadata:   metadata,
+				Spec: kubernetesCronJobSpec{
+					Schedule:          schedule,
+					TimeZone:          zone,
+					ConcurrencyPolicy: "Forbid",
+					JobTemplate: kubernetesJobTemplate{
+						Spec: kubernetesJobSpec{
+							Template: kubernetesPodTemplate{
+								Metadata: kubernetesMetadata{Labels: metadata.Labels},
+								Spec:     kubernetesPodSpec{RestartPolicy: "OnFailure", Containers: []kubernetesContainer{c}},
+							},
+						},
+					},
+				},
+			}
+		} else {
+			var replicas *int
+			if p.Formation != nil {
+				replicas = p.Formation.Replicas
+			}
+
+			object = kubernetesDeployment{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Metadata:   metadata,
+				Spec: kubernetesDeploymentSpec{
+					Replicas: replicas,
+					Selector: kubernetesSelector{MatchLabels: metadata.Labels},
+					Template: kubernetesPodTemplate{
+						Metadata: kubernetesMetadata{Labels: metadata.Labels},
+						Spec:     kubernetesPodSpec{Containers: []kubernetesContainer{c}},
+					},
+				},
+			}
+		}
+
+		if err := e.Encode(object); err != nil {
+			return nil, fmt.Errorf("unable to encode process type %s\n%w", p.Type, err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encode %s\n%w", KubernetesFile, err)
+	}
+
+	return []File{{Name: KubernetesFile, Content: b.Bytes()}}, nil
+}
+
+func newKubernetesContainer(application Application, p Process) (kubernetesContainer, error) {
+	c := kubernetesContainer{
+		Name:       kubernetesName(p.Type),
+		Image:      application.Image,
+		Command:    []string{ProcessPath + p.Type},
+		WorkingDir: application.WorkingDirectory,
+	}
+
+	for _, k := range sortedKeys(application.Environment) {
+		c.Env = append(c.Env, kubernetesEnvVar{Name: k, Value: application.Environment[k]})
+	}
+
+	if p.Port != 0 {
+		c.Ports = []kubernetesPort{{Name: "http", ContainerPort: p.Port}}
+	}
+
+	if p.Formation != nil && (p.Formation.CPU != "" || p.Formation.Memory != "") {
+		c.Resources = &kubernetesResources{Requests: map[string]string{}}
+		if p.Formation.CPU != "" {
+			c.Resources.Requests["cpu"] = p.Formation.CPU
+		}
+		if p.Formation.Memory != "" {
+			c.Resources.Requests["memory"] = p.Formation.Memory
+			c.Resources.Limits = map[string]string{"memory": p.Formation.Memory}
+		}
+	}
+
+	if p.HealthCheck != nil && p.Schedule == "" {
+		probe, err := newKubernetesProbe(*p.HealthCheck, p.Port)
+		if err != nil {
+			return kubernetesContainer{}, err
+		}
+		c.ReadinessProbe, c.LivenessProbe = probe, probe
+	}
+
+	return c, nil
+}
+
+func newKubernetesProbe(h procfile.HealthCheck, port int) (*kubernetesProbe, error) {
+	probe := &kubernetesProbe{}
+
+	if h.Path != "" {
+		if h.Port != 0 {
+			port = h.Port
+		}
+		if port == 0 {
+			return nil, fmt.Errorf("health check path %s requires the port of the health check or of the process type", h.Path)
+		}
+		probe.HTTPGet = &kubernetesHTTPGet{Path: h.Path, Port: port}
+	} else {
+		probe.Exec = &kubernetesExec{Command: launcherCommand(h.Command)}
+	}
+
+	var err error
+	if probe.PeriodSeconds, err = seconds(h.Interval); err != nil {
+		return nil, err
+	}
+	if probe.TimeoutSeconds, err = seconds(h.Timeout); err != nil {
+		return nil, err
+	}
+
+	return probe, nil
+}
+
+// kubernetesName returns s as a valid Kubernetes object name.
+func kubernetesName(s string) string {
+	s = strings.Trim(invalidName.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(s) > 63 {
+		s = strings.TrimRight(s[:63], "-")
+	}
+	return s
+}
+
+// seconds returns the duration s rounded up to whole seconds, 0 if s is empty.
+func seconds(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse duration %s\n%w", s, err)
+	}
+
+	return int(math.Max(1, math.Ceil(d.Seconds()))), nil
+}
+
+type kubernetesMetadata struct {
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type kubernetesDeployment struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   kubernetesMetadata       `yaml:"metadata"`
+	Spec       kubernetesDeploymentSpec `yaml:"spec"`
+}
+
+type kubernetesDeploymentSpec struct {
+	Replicas *int                  `yaml:"replicas,omitempty"`
+	Selector kubernetesSelector    `yaml:"selector"`
+	Template kubernetesPodTemplate `yaml:"template"`
+}
+
+type kubernetesSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type kubernetesCronJob struct {
+	APIVersion string                `yaml:"apiVersion"`
+	Kind       string                `yaml:"kind"`
+	Metadata   kubernetesMetadata    `yaml:"metadata"`
+	Spec       kubernetesCronJobSpec `yaml:"spec"`
+}
+
+type kubernetesCronJobSpec struct {
+	Schedule          string                `yaml:"schedule"`
+	TimeZone          string                `yaml:"timeZone,omitempty"`
+	ConcurrencyPolicy string                `yaml:"concurrencyPolicy"`
+	JobTemplate       kubernetesJobTemplate `yaml:"jobTemplate"`
+}
+
+type kubernetesJobTemplate struct {
+	Spec kubernetesJobSpec `yaml:"spec"`
+}
+
+type kubernetesJobSpec struct {
+	Template kubernetesPodTemplate `yaml:"template"`
+}
+
+type kubernetesPodTemplate struct {
+	Metadata kubernetesMetadata `yaml:"metadata"`
+	Spec     kubernetesPodSpec  `yaml:"spec"`
+}
+
+type kubernetesPodSpec struct {
+	RestartPolicy string                `yaml:"restartPolicy,omitempty"`
+	Containers    []kubernetesContainer `yaml:"containers"`
+}
+
+type kubernetesContainer struct {
+	Name           string               `yaml:"name"`
+	Image          string               `yaml:"image"`
+	Command        []string             `yaml:"command"`
+	WorkingDir     string               `yaml:"workingDir,omitempty"`
+	Env            []kubernetesEnvVar   `yaml:"env,omitempty"`
+	Ports          []kubernetesPort     `yaml:"ports,omitempty"`
+	Resources      *kubernetesResources `yaml:"resources,omitempty"`
+	ReadinessProbe *kubernetesProbe     `yaml:"readinessProbe,omitempty"`
+	LivenessProbe  *kubernetesProbe     `yaml:"livenessProbe,omitempty"`
+}
+
+type kubernetesEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type kubernetesPort struct {
+	Name          string `yaml:"name"`
+	ContainerPort int    `yaml:"containerPort"`
+}
+
+type kubernetesResources struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+type kubernetesProbe struct {
+	HTTPGet        *kubernetesHTTPGet `yaml:"httpGet,omitempty"`
+	Exec           *kubernetesExec    `yaml:"exec,omitempty"`
+	PeriodSeconds  int                `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds int                `yaml:"timeoutSeconds,omitempty"`
+}
+
+type kubernetesHTTPGet struct {
+	Path string `yaml:"path"`
+	Port int    `yaml:"port"`
+}
+
+type kubernetesExec struct {
+	Command []string `yaml:"command"`
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testKubernetes(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	render := func(p procfile.Procfile) []export.File {
+		t.Helper()
+
+		application, err := export.NewApplication("My_App", "registry.example.com/my-app:1.0.0", p, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		files, err := export.Kubernetes(application)
+		Expect(err).NotTo(HaveOccurred())
+		return files
+	}
+
+	it("renders a deployment per process type", func() {
+		expectGolden(t, "kubernetes/deployments", render(procfile.Procfile{
+			"web":    "bundle exec rails server -p ${PORT:-3000}",
+			"worker": "bundle exec sidekiq",
+		}))
+	})
+
+	it("renders ports, health checks and resources", func() {
+		expectGolden(t, "kubernetes/attributes", render(procfile.Procfile{
+			"web": map[string]interface{}{
+				"command":      "web-command",
+				"port":         8080,
+				"health-check": map[string]interface{}{"path": "/health", "interval": "10s", "timeout": "1500ms"},
+				"formation":    map[string]interface{}{"replicas": 3, "cpu": "500m", "memory": "1Gi"},
+			},
+			"worker": map[string]interface{}{
+				"command":      "worker-command",
+				"health-check": map[string]interface{}{"command": "test -f /tmp/healthy"},
+				"formation":    map[string]interface{}{"replicas": 0},
+			},
+		}))
+	})
+
+	it("renders a cron job per scheduled process type", func() {
+		expectGolden(t, "kubernetes/cron-jobs", render(procfile.Procfile{
+			"report": map[string]interface{}{"command": "report-command", "schedule": "CRON_TZ=Europe/Berlin 0 6 * * MON-FRI"},
+			"clock":  map[string]interface{}{"command": "clock-command", "schedule": "*/5 * * * *", "formation": map[string]interface{}{"memory": "256Mi"}},
+		}))
+	})
+
+	it("returns error for an HTTP health check without port", func() {
+		application, err := export.NewApplication("app", "app", procfile.Procfile{
+			"web": map[string]interface{}{"command": "web-command", "health-check": map[string]interface{}{"path": "/health"}},
+		}, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = export.Kubernetes(application)
+		Expect(err).To(MatchError("unable to render process type web\nhealth check path /health requires the port of the health check or of the process type"))
+	})
+
+	it("runs compound health check commands with the launcher within bash", func() {
+		files := render(procfile.Procfile{
+			"worker": map[string]interface{}{
+				"command":      "worker-command",
+				"health-check": map[string]interface{}{"command": "test -f /tmp/healthy && test -f /tmp/ready"},
+			},
+		})
+
+		Expect(string(files[0].Content)).To(ContainSubstring("- /cnb/lifecycle/launcher\n                - test -f /tmp/healthy && test -f /tmp/ready\n"))
+	})
+
+	it("returns error for process types with the same Kubernetes name", func() {
+		for _, types := range [][]string{{"my-web", "my_web"}, {"Web", "web"}} {
+			application, err := export.NewApplication("app", "app", procfile.Procfile{
+				types[0]: "web-command",
+				types[1]: "web-command",
+			}, procfile.Execution{Shell: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = export.Kubernetes(application)
+			Expect(err).To(MatchError(fmt.Sprintf("unable to render process type %s, its Kubernetes name app-%s is the name of process type %s",
+				types[1], strings.ToLower(types[0]), types[0])))
+		}
+	})
+
+	it("returns error for process types with the same Kubernetes component", func() {
+		application, err := export.NewApplication("app", "app", procfile.Procfile{
+			"-web": "web-command",
+			"web":  "web-command",
+		}, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = export.Kubernetes(application)
+		Expect(err).To(MatchError("unable to render process type web, its Kubernetes component web is the component of process type -web"))
+	})
+}
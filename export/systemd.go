@@ -0,0 +1,264 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// invalidUnitName matches the characters not allowed in systemd unit names.
+	invalidUnitName = regexp.MustCompile(`[^a-zA-Z0-9:_.\\-]+`)
+
+	// calendarDescriptors are the systemd calendar events of cron descriptors.
+	calendarDescriptors = map[string]string{
+		"@yearly":   "yearly",
+		"@annually": "yearly",
+		"@monthly":  "monthly",
+		"@weekly":   "weekly",
+		"@daily":    "daily",
+		"@midnight": "daily",
+		"@hourly":   "hourly",
+	}
+
+	// months are the names of the months of cron schedules.
+	months = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+	// weekdays are the names of the days of the week of cron schedules and their systemd names.
+	weekdays = map[string]string{
+		"0": "Sun", "1": "Mon", "2": "Tue", "3": "Wed", "4": "Thu", "5": "Fri", "6": "Sat", "7": "Sun",
+		"SUN": "Sun", "MON": "Mon", "TUE": "Tue", "WED": "Wed", "THU": "Thu", "FRI": "Fri", "SAT": "Sat",
+	}
+)
+
+// SystemdContainerRuntime is the container runtime executing the images of the units.
+const SystemdContainerRuntime = "/usr/bin/podman"
+
+// Systemd renders an application as systemd units running its image with the container runtime: a service per process
+// type, starting the process type with its launcher entrypoint, a timer per scheduled process type and a target
+// starting all of them. The containers run with the environment and working directory of the application and publish
+// the port of the process type, if known, and the services with the restart policy of the application.
+func Systemd(application Application) ([]File, error) {
+	if application.Image == "" {
+		return nil, fmt.Errorf("an image is required")
+	}
+
+	name := unitName(application.Name)
+
+	var files []File
+	var wants []string
+	for _, p := range application.Processes {
+		unit := unitName(application.Name + "-" + p.Type)
+
+		b := &strings.Builder{}
+		_, _ = fmt.Fprintf(b, "[Unit]\nDescription=%s process type %s\nPartOf=%s.target\n", escapeSpecifiers(application.Name), escapeSpecifiers(p.Type), name)
+		_, _ = fmt.Fprintf(b, "Wants=network-online.target\nAfter=network-online.target\n")
+		_, _ = fmt.Fprintf(b, "\n[Service]\n")
+		if p.Schedule != "" {
+			_, _ = fmt.Fprintf(b, "Type=oneshot\n")
+		}
+		_, _ = fmt.Fprintf(b, "ExecStart=%s\n", systemdCommand(application, p, unit))
+		if p.Schedule == "" {
+			_, _ = fmt.Fprintf(b, "Restart=%s\n", application.Restart)
+			_, _ = fmt.Fprintf(b, "\n[Install]\nWantedBy=%s.target\n", name)
+			wants = append(wants, unit+".service")
+		}
+		files = append(files, File{Name: unit + ".service", Content: []byte(b.String())})
+
+		if p.Schedule != "" {
+			timer, err := systemdTimer(p.Schedule)
+			if err != nil {
+				return nil, fmt.Errorf("unable to render process type %s\n%w", p.Type, err)
+			}
+
+			b := &strings.Builder{}
+			_, _ = fmt.Fprintf(b, "[Unit]\nDescription=%s process type %s schedule\nPartOf=%s.target\n", escapeSpecifiers(application.Name), escapeSpecifiers(p.Type), name)
+			_, _ = fmt.Fprintf(b, "\n[Timer]\n%s", timer)
+			_, _ = fmt.Fprintf(b, "\n[Install]\nWantedBy=%s.target\n", name)
+			files = append(files, File{Name: unit + ".timer", Content: []byte(b.String())})
+			wants = append(wants, unit+".timer")
+		}
+	}
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "[Unit]\nDescription=%s\nWants=%s\n", escapeSpecifiers(application.Name), strings.Join(wants, " "))
+	_, _ = fmt.Fprintf(b, "\n[Install]\nWantedBy=multi-user.target\n")
+	files = append(files, File{Name: name + ".target", Content: []byte(b.String())})
+
+	return files, nil
+}
+
+// systemdCommand returns the ExecStart command line running a process type of an application in a container named
+// unit, which replaces a container left over by a previous run and is removed when it exits.
+func systemdCommand(application Application, p Process, unit string) string {
+	args := []string{SystemdContainerRuntime, "run", "--rm", "--replace", "--name", unit, "--entrypoint", ProcessPath + p.Type}
+	if application.WorkingDirectory != "" {
+		args = append(args, "--workdir", application.WorkingDirectory)
+	}
+	for _, k := range sortedKeys(application.Environment) {
+		args = append(args, "--env", k+"="+application.Environment[k])
+	}
+	if p.Port != 0 {
+		args = append(args, "--publish", fmt.Sprintf("%d:%d", p.Port, p.Port))
+	}
+	args = append(args, application.Image)
+
+	for i, a := range args {
+		args[i] = quoteUnitArgument(a)
+	}
+	return strings.Join(args, " ")
+}
+
+// systemdTimer returns the [Timer] settings of a cron schedule.
+func systemdTimer(schedule string) (string, error) {
+	zone := ""
+	if m := timeZone.FindStringSubmatch(schedule); m != nil {
+		schedule, zone = strings.TrimPrefix(schedule, m[0]), " "+m[2]
+	}
+
+	if strings.HasPrefix(schedule, "@every ") {
+		d, err := time.ParseDuration(strings.TrimPrefix(schedule, "@every "))
+		if err != nil {
+			return "", fmt.Errorf("unable to parse schedule %s\n%w", schedule, err)
+		}
+		return fmt.Sprintf("OnActiveSec=%s\nOnUnitActiveSec=%s\n", d, d), nil
+	}
+
+	if c, ok := calendarDescriptors[schedule]; ok {
+		return fmt.Sprintf("OnCalendar=%s%s\n", c, zone), nil
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return "", fmt.Errorf("unable to translate schedule %s, expected five fields", schedule)
+	}
+	if fields[2] != "*" && fields[4] != "*" {
+		return "", fmt.Errorf("unable to translate schedule %s, restricting both the day of the month and of the week is not supported", schedule)
+	}
+
+	var calendar [5]string
+	for i, f := range fields {
+		c, err := calendarField(f, i)
+		if err != nil {
+			return "", fmt.Errorf("unable to translate schedule %s\n%w", schedule, err)
+		}
+		calendar[i] = c
+	}
+
+	weekday := ""
+	if calendar[4] != "*" {
+		weekday = calendar[4] + " "
+	}
+
+	return fmt.Sprintf("OnCalendar=%s*-%s-%s %s:%s:00%s\n", weekday, calendar[3], calendar[2], calendar[1], calendar[0], zone), nil
+}
+
+// calendarField translates the cron field f at index i, one of minute, hour, day of month, month and day of week, to
+// the corresponding systemd calendar event component.
+func calendarField(f string, i int) (string, error) {
+	if f == "*" {
+		return "*", nil
+	}
+
+	var values []string
+	for _, item := range strings.Split(f, ",") {
+		base, step, stepped := strings.Cut(item, "/")
+
+		if stepped {
+			if _, err := strconv.Atoi(step); err != nil {
+				return "", fmt.Errorf("invalid step %s", item)
+			}
+			if strings.Contains(base, "-") || i == 4 {
+				return "", fmt.Errorf("unsupported step %s", item)
+			}
+			if base == "*" {
+				base = "0"
+				if i == 2 || i == 3 {
+					base = "1"
+				}
+			}
+		}
+
+		var bounds []string
+		for _, v := range strings.SplitN(base, "-", 2) {
+			c, err := calendarValue(v, i)
+			if err != nil {
+				return "", err
+			}
+			bounds = append(bounds, c)
+		}
+
+		value := strings.Join(bounds, "..")
+		if stepped {
+			value += "/" + step
+		}
+		values = append(values, value)
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+// calendarValue translates a single value of the cron field at index i, resolving month and weekday names.
+func calendarValue(v string, i int) (string, error) {
+	switch i {
+	case 3:
+		for j, m := range months {
+			if strings.EqualFold(v, m) {
+				return strconv.Itoa(j + 1), nil
+			}
+		}
+	case 4:
+		if w, ok := weekdays[strings.ToUpper(v)]; ok {
+			return w, nil
+		}
+		return "", fmt.Errorf("invalid day of the week %s", v)
+	}
+
+	if _, err := strconv.Atoi(v); err != nil {
+		return "", fmt.Errorf("invalid value %s", v)
+	}
+	return v, nil
+}
+
+// unitName returns s as a valid systemd unit name.
+func unitName(s string) string {
+	return strings.Trim(invalidUnitName.ReplaceAllString(s, "-"), "-")
+}
+
+// escapeSpecifiers escapes the % specifiers expanded by systemd in s.
+func escapeSpecifiers(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// quoteUnitValue double quotes s for a unit setting, escaping backslashes, double quotes and newlines.
+func quoteUnitValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// quoteUnitArgument quotes s as an argument of a command line of a unit, so that systemd does not split it and does not
+// expand variables or specifiers in it.
+func quoteUnitArgument(s string) string {
+	s = strings.NewReplacer("$", "$$", "%", "%%").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\;") {
+		return s
+	}
+	return quoteUnitValue(s)
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package export_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/export"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testSystemd(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	newApplication := func(p procfile.Procfile, execution procfile.Execution) export.Application {
+		t.Helper()
+
+		application, err := export.NewApplication("my-app", "registry.example.com/my-app:1.0.0", p, execution)
+		Expect(err).NotTo(HaveOccurred())
+		return application
+	}
+
+	it("renders a service per process type and a target", func() {
+		files, err := export.Systemd(newApplication(procfile.Procfile{
+			"web":    `bundle exec rails server -p ${PORT:-3000} -e "$RAILS_ENV"`,
+			"worker": "bundle exec sidekiq",
+		}, procfile.Execution{Shell: true}))
+		Expect(err).NotTo(HaveOccurred())
+
+		expectGolden(t, "systemd/services", files)
+	})
+
+	it("renders environment, working directory, ports, restart policy and timers", func() {
+		application := newApplication(procfile.Procfile{
+			"web":    map[string]interface{}{"command": "bin/server --config 'my config.yml'", "port": int64(8080)},
+			"worker": "/usr/bin/worker",
+			"clock":  map[string]interface{}{"command": "bin/clock", "schedule": "CRON_TZ=Europe/Berlin */15 8-18 * * MON-FRI"},
+		}, procfile.Execution{Direct: true, Shell: true})
+		application.WorkingDirectory = "/opt/my-app"
+		application.Environment = map[string]string{"RAILS_ENV": "production", "GREETING": `say "hi"`, "LOG_FORMAT": "%h $USER"}
+		application.Restart = export.RestartOnFailure
+
+		files, err := export.Systemd(application)
+		Expect(err).NotTo(HaveOccurred())
+
+		expectGolden(t, "systemd/attributes", files)
+	})
+
+	context("schedules", func() {
+		timer := func(schedule string) (string, error) {
+			files, err := export.Systemd(newApplication(procfile.Procfile{
+				"clock": map[string]interface{}{"command": "clock-command", "schedule": schedule},
+			}, procfile.Execution{Shell: true}))
+			if err != nil {
+				return "", err
+			}
+			return string(files[1].Content), nil
+		}
+
+		for schedule, expected := range map[string]string{
+			"*/5 * * * *":                     "OnCalendar=*-*-* *:0/5:00\n",
+			"0 3 1,15 * *":                    "OnCalendar=*-*-1,15 3:0:00\n",
+			"30 6 * JAN-MAR,DEC SUN":          "OnCalendar=Sun *-1..3,12-* 6:30:00\n",
+			"0 0 */2 * *":                     "OnCalendar=*-*-1/2 0:0:00\n",
+			"TZ=UTC 0 12 * * 1-5":             "OnCalendar=Mon..Fri *-*-* 12:0:00 UTC\n",
+			"@weekly":                         "OnCalendar=weekly\n",
+			"CRON_TZ=America/New_York @daily": "OnCalendar=daily America/New_York\n",
+			"@every 1h30m":                    "OnActiveSec=1h30m0s\nOnUnitActiveSec=1h30m0s\n",
+		} {
+			it("translates schedule "+schedule, func() {
+				Expect(timer(schedule)).To(ContainSubstring("[Timer]\n" + expected))
+			})
+		}
+
+		for schedule, message := range map[string]string{
+			"0 0 1 * MON":   "restricting both the day of the month and of the week is not supported",
+			"0 0 * * */2":   "unsupported step */2",
+			"0 1-5/2 * * *": "unsupported step 1-5/2",
+		} {
+			it("returns error for schedule "+schedule, func() {
+				_, err := timer(schedule)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			})
+		}
+	})
+
+	it("returns error without image", func() {
+		application := newApplication(procfile.Procfile{"web": "bin/server"}, procfile.Execution{Shell: true})
+		application.Image = ""
+
+		_, err := export.Systemd(application)
+		Expect(err).To(MatchError("an image is required"))
+	})
+}
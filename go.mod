@@ -3,16 +3,18 @@ module github.com/paketo-buildpacks/procfile/v5
 go 1.26
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/Masterminds/semver/v3 v3.5.0
 	github.com/buildpacks/libcnb v1.30.4
 	github.com/mattn/go-shellwords v1.0.14
 	github.com/onsi/gomega v1.42.1
 	github.com/paketo-buildpacks/libpak v1.73.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sclevine/spec v1.4.0
+	go.yaml.in/yaml/v3 v3.0.5
 )
 
 require (
-	github.com/BurntSushi/toml v1.6.0 // indirect
-	github.com/Masterminds/semver/v3 v3.5.0 // indirect
 	github.com/creack/pty v1.1.24 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/heroku/color v0.0.6 // indirect
@@ -20,7 +22,6 @@ require (
 	github.com/mattn/go-colorable v0.1.15 // indirect
 	github.com/mattn/go-isatty v0.0.24 // indirect
 	github.com/mitchellh/hashstructure/v2 v2.0.2 // indirect
-	go.yaml.in/yaml/v3 v3.0.5 // indirect
 	golang.org/x/net v0.57.0 // indirect
 	golang.org/x/sys v0.47.0 // indirect
 	golang.org/x/text v0.40.0 // indirect
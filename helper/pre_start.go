@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+	"github.com/paketo-buildpacks/procfile/v5/supervisor"
+)
+
+// PreStart runs a pre-start hook before a process type starts.
+type PreStart struct {
+	Hook   procfile.Hook
+	Shell  string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewPreStartHelpers creates a PreStart helper for each hook configured in path, named after the exec.d links created
+// for it. Returns no helpers if path does not exist.
+func NewPreStartHelpers(path string) (map[string]sherpa.ExecD, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return map[string]sherpa.ExecD{}, nil
+	}
+
+	c, err := procfile.NewHooksConfigurationFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	helpers := map[string]sherpa.ExecD{}
+	for _, h := range c.Hooks {
+		helpers[procfile.PreStartHelperPrefix+h.Type] = PreStart{Hook: h, Shell: supervisor.DefaultShell, Stdout: os.Stdout, Stderr: os.Stderr}
+	}
+	return helpers, nil
+}
+
+func (p PreStart) Execute() (map[string]string, error) {
+	var cmd *exec.Cmd
+	if p.Hook.Direct {
+		cmd = exec.Command(p.Hook.Command, p.Hook.Arguments...)
+	} else {
+		cmd = exec.Command(p.Shell, append([]string{"-c", p.Hook.Command, p.Hook.Type}, p.Hook.Arguments...)...)
+	}
+	cmd.Stdout = p.Stdout
+	cmd.Stderr = p.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if p.Hook.OnFailure == procfile.OnFailureContinue {
+			_, _ = fmt.Fprintf(p.Stderr, "Pre-start hook %s failed, continuing: %s\n", p.Hook.Type, err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to run pre-start hook %s\n%w", p.Hook.Type, err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helper_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/helper"
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testPreStart(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stdout, stderr *bytes.Buffer
+	)
+
+	it.Before(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	})
+
+	it("runs direct hooks", func() {
+		p := helper.PreStart{
+			Hook:   procfile.Hook{Type: "release", Command: "echo", Arguments: []string{"migrating"}, Direct: true},
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+
+		Expect(p.Execute()).To(BeEmpty())
+		Expect(stdout.String()).To(Equal("migrating\n"))
+	})
+
+	it("runs hooks within a shell", func() {
+		p := helper.PreStart{
+			Hook:   procfile.Hook{Type: "release", Command: "echo migrating && echo done"},
+			Shell:  "bash",
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+
+		Expect(p.Execute()).To(BeEmpty())
+		Expect(stdout.String()).To(Equal("migrating\ndone\n"))
+	})
+
+	it("runs hooks with the environment exported by the profile scripts", func() {
+		path := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte("export DATABASE_URL=postgres://localhost/app\n"), 0644)).To(Succeed())
+
+		env, err := helper.Profile{Path: path}.Execute()
+		Expect(err).NotTo(HaveOccurred())
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+
+		p := helper.PreStart{
+			Hook:   procfile.Hook{Type: "release", Command: "echo migrating $DATABASE_URL"},
+			Shell:  "bash",
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+
+		Expect(p.Execute()).To(BeEmpty())
+		Expect(stdout.String()).To(Equal("migrating postgres://localhost/app\n"))
+	})
+
+	it("returns error when a hook fails", func() {
+		p := helper.PreStart{
+			Hook:   procfile.Hook{Type: "release", Command: "exit 3", OnFailure: procfile.OnFailureFail},
+			Shell:  "bash",
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+
+		_, err := p.Execute()
+		Expect(err).To(MatchError("unable to run pre-start hook release\nexit status 3"))
+	})
+
+	it("continues when a hook fails if configured", func() {
+		p := helper.PreStart{
+			Hook:   procfile.Hook{Type: "release", Command: "exit 3", OnFailure: procfile.OnFailureContinue},
+			Shell:  "bash",
+			Stdout: stdout,
+			Stderr: stderr,
+		}
+
+		Expect(p.Execute()).To(BeEmpty())
+		Expect(stderr.String()).To(Equal("Pre-start hook release failed, continuing: exit status 3\n"))
+	})
+
+	it("creates a helper per configured hook", func() {
+		path := filepath.Join(t.TempDir(), "hooks.toml")
+		f, err := os.Create(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(toml.NewEncoder(f).Encode(procfile.HooksConfiguration{Hooks: []procfile.Hook{
+			{Type: "release", Command: "release-command", Types: []string{"web"}},
+			{Type: "migrate", Command: "migrate-command", Types: []string{"web"}},
+		}})).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+
+		helpers, err := helper.NewPreStartHelpers(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(helpers).To(HaveLen(2))
+		Expect(helpers).To(HaveKey("pre-start-release"))
+		Expect(helpers).To(HaveKey("pre-start-migrate"))
+		Expect(helpers["pre-start-release"].(helper.PreStart).Shell).To(Equal("bash"))
+	})
+
+	it("creates no helpers without configuration", func() {
+		Expect(helper.NewPreStartHelpers(filepath.Join(t.TempDir(), "hooks.toml"))).To(BeEmpty())
+	})
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helper
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+// Profile evaluates the profile scripts of the application into the environment of direct processes.
+type Profile struct {
+
+	// Path is the path to the application, CNB_APP_DIR or the working directory if empty.
+	Path string
+}
+
+func (p Profile) Execute() (map[string]string, error) {
+	path := p.Path
+	if path == "" {
+		path = os.Getenv("CNB_APP_DIR")
+	}
+	if path == "" {
+		var err error
+		if path, err = os.Getwd(); err != nil {
+			return nil, fmt.Errorf("unable to get working directory\n%w", err)
+		}
+	}
+
+	scripts, err := procfile.NewProfileScripts(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var assignments []procfile.Assignment
+	for _, s := range scripts {
+		a, err := procfile.NewAssignments(s)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a...)
+	}
+
+	return procfile.Evaluate(assignments, os.LookupEnv), nil
+}
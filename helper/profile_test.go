@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helper_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/helper"
+)
+
+func testProfile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = t.TempDir()
+		t.Setenv("HOME", "/home/cnb")
+	})
+
+	it("evaluates .profile.d scripts and .profile", func() {
+		Expect(os.MkdirAll(filepath.Join(path, ".profile.d"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, ".profile.d", "app.sh"), []byte("export APP_HOME=$HOME/app\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte("export APP_CONFIG=\"$APP_HOME/config\"\n"), 0644)).To(Succeed())
+
+		Expect(helper.Profile{Path: path}.Execute()).To(Equal(map[string]string{
+			"APP_HOME":   "/home/cnb/app",
+			"APP_CONFIG": "/home/cnb/app/config",
+		}))
+	})
+
+	it("reads the application from CNB_APP_DIR", func() {
+		t.Setenv("CNB_APP_DIR", path)
+		Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte("export APP_ENV=test\n"), 0644)).To(Succeed())
+
+		Expect(helper.Profile{}.Execute()).To(Equal(map[string]string{"APP_ENV": "test"}))
+	})
+
+	it("returns error for scripts requiring a shell", func() {
+		Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte("source .env\n"), 0644)).To(Succeed())
+
+		_, err := helper.Profile{Path: path}.Execute()
+		Expect(err).To(MatchError(ContainSubstring("requires a shell")))
+	})
+}
@@ -17,13 +17,18 @@
 package procfile
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/buildpacks/libcnb"
-	"github.com/mattn/go-shellwords"
-	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/sherpa"
 )
@@ -32,50 +37,382 @@ type Build struct {
 	Logger bard.Logger
 }
 
-func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
+func (b Build) Build(context libcnb.BuildContext) (result libcnb.BuildResult, err error) {
 	b.Logger.Title(context.Buildpack)
-	result := libcnb.NewBuildResult()
+	result = libcnb.NewBuildResult()
 
-	r := libpak.PlanEntryResolver{Plan: context.Plan}
-	e, ok, err := r.Resolve("procfile")
+	variables := NewVariables(context.Platform.Bindings)
+
+	// Errors might quote commands, which must not reveal the secrets they contain
+	defer func() {
+		if err != nil {
+			err = errors.New(RedactSecrets(variables.Redact(err.Error())))
+		}
+	}()
+
+	app, contributions, err := NewProcfileFromPlan(context.Plan)
 	if err != nil {
 		return libcnb.BuildResult{}, fmt.Errorf("unable to resolve buildpack plan entry procfile\n%w", err)
-	} else if !ok {
+	} else if app == nil && len(contributions) == 0 {
 		return libcnb.BuildResult{}, nil
 	}
 
-	for k, v := range e.Metadata {
-		process := libcnb.Process{Type: k}
-
-		if libpak.IsTinyStack(context.StackID) || sherpa.ResolveBool("BP_DIRECT_PROCESS") {
-			s, err := shellwords.Parse(v.(string))
-			if err != nil {
-				return libcnb.BuildResult{}, fmt.Errorf("unable to parse %s\n%w", s, err)
+	_, report := Merge(contributions...)
+	for _, e := range report {
+		for _, s := range e.Shadowed {
+			if s.Source != e.Source && !reflect.DeepEqual(s.Command, e.Command) {
+				b.Logger.Headerf("Process type %s contributed by %s conflicts with %s, using %s", e.Type, s.Source, e.Source, e.Source)
 			}
+		}
+	}
+
+	// The sources are read again only to report them, unless rescanning, so failing to read them must not fail the build
+	sources, err := NewSources(context.Application.Path, context.Platform.Bindings)
+	if err != nil && sherpa.ResolveBool("BP_PROCFILE_RESCAN") {
+		return libcnb.BuildResult{}, fmt.Errorf("unable to read Procfile sources\n%w", err)
+	} else if err != nil {
+		b.Logger.Headerf("Warning: unable to read Procfile sources, reporting process types as passed through the build plan: %s",
+			strings.ReplaceAll(err.Error(), "\n", ": "))
+		sources = nil
+	}
 
-			process.Command = s[0]
-			process.Arguments = s[1:]
-			process.Direct = true
+	// Substitutions were already logged during detection, and failing ones only fail the build when rescanning
+	if sherpa.ResolveBool("BP_PROCFILE_RESCAN") && sherpa.ResolveBool("BP_PROCFILE_SUBSTITUTE") {
+		if sources, err = variables.SubstituteSources(sources, b.Logger); err != nil {
+			return libcnb.BuildResult{}, err
+		}
+	} else if sherpa.ResolveBool("BP_PROCFILE_SUBSTITUTE") {
+		if s, err := variables.SubstituteSources(sources, bard.NewLogger(io.Discard)); err != nil {
+			b.Logger.Headerf("Warning: unable to substitute placeholders of Procfile sources, reporting them unchanged: %s",
+				strings.ReplaceAll(variables.Redact(err.Error()), "\n", ": "))
 		} else {
-			process.Command = v.(string)
-			process.Direct = false
+			sources = s
 		}
+	}
+
+	// A Procfile generated by an earlier buildpack during build was not seen during detection
+	if sherpa.ResolveBool("BP_PROCFILE_RESCAN") {
+		current, _ := Merge(sources...)
+
+		var late []string
+		for k, v := range current {
+			if _, ok := app[k]; !ok {
+				if app == nil {
+					app = Procfile{}
+				}
+				app[k] = v
+				late = append(late, k)
+			}
+		}
+
+		if len(late) > 0 {
+			sort.Strings(late)
+			b.Logger.Headerf("Discovered process types %s during build", strings.Join(late, ", "))
+		}
+	}
 
-		result.Processes = append(result.Processes, process)
+	merged, _ := Merge(append(contributions, Source{Name: SourcePlan, Procfile: app})...)
+	_, report = Merge(append(contributions, sources...)...)
+	report.Match(merged).Redact(variables.Redact).Redact(RedactSecrets).Log(b.Logger)
+
+	p, removed := NewFilterFromEnvironment().Apply(merged)
+	if len(removed) > 0 {
+		b.Logger.Headerf("Removing process types %s, as configured by BP_PROCFILE_INCLUDE_TYPES and BP_PROCFILE_EXCLUDE_TYPES",
+			strings.Join(removed, ", "))
+	}
+
+	if err := b.secrets(p); err != nil {
+		return libcnb.BuildResult{}, err
 	}
 
-	markDefaultProcess(result)
+	shell, err := NewShellAvailability(context.StackID)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	}
+	if !shell.Available {
+		b.Logger.Headerf("Executing processes directly, %s", shell.Reason)
+	} else if sherpa.ResolveBool("BP_DIRECT_PROCESS") {
+		b.Logger.Header("Executing processes directly, as configured by BP_DIRECT_PROCESS")
+	}
+
+	interpolate, err := supportsInterpolation(context.Buildpack.API)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	}
+
+	pipeline := NewPipeline(shell.Available, interpolate, b.Logger)
+	processes, err := pipeline.Processes(p)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	}
+	result.Processes = append(result.Processes, processes...)
+
+	schedules, err := NewSchedules(p)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	}
+
+	markDefaultProcess(result, schedules)
+
+	hooks, err := NewHooks(p, result.Processes)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	}
+
+	if sherpa.ResolveBool("BP_PROCFILE_SUPERVISOR") {
+		s, err := b.supervisor(context, result.Processes, hooks, schedules)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+
+		result.Layers = append(result.Layers, s)
+		result.Processes = append(result.Processes, s.Process(context.Layers))
+
+		// Supervised processes are not started by the launcher, hooks run before the supervisor instead
+		for i, h := range hooks {
+			if slices.ContainsFunc(s.Processes, func(p libcnb.Process) bool { return slices.Contains(h.Types, p.Type) }) {
+				hooks[i].Types = append(slices.Clone(h.Types), SupervisorProcessType)
+				sort.Strings(hooks[i].Types)
+			}
+		}
+	}
+
+	if len(hooks) > 0 {
+		h := NewHooksLayer(context.Buildpack, hooks)
+		h.Logger = b.Logger
+		result.Layers = append(result.Layers, h)
+	}
+
+	profile, err := b.profile(context, result.Processes, pipeline.Execution.Shell)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	} else if profile != nil {
+		result.Layers = append(result.Layers, *profile)
+	}
 
 	sort.Slice(result.Processes, func(i int, j int) bool {
 		return result.Processes[i].Type < result.Processes[j].Type
 	})
 
+	if err := b.policy(context, result.Processes); err != nil {
+		return libcnb.BuildResult{}, err
+	}
+
+	ports, inferred, err := NewPorts(p)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	} else if len(ports) > 0 {
+		if len(inferred) > 0 {
+			b.Logger.Headerf("Inferred ports of process types %s from their commands", strings.Join(inferred, ", "))
+		}
+
+		label, err := NewLabel(PortsLabel, ports)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+		result.Labels = append(result.Labels, label)
+	}
+
+	formations, err := NewFormations(p)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	} else if len(formations) > 0 {
+		label, err := NewLabel(FormationLabel, formations)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+		result.Labels = append(result.Labels, label)
+	}
+
+	if len(schedules) > 0 {
+		label, err := NewLabel(SchedulesLabel, schedules)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+		result.Labels = append(result.Labels, label)
+	}
+
+	checks, err := NewHealthChecks(p)
+	if err != nil {
+		return libcnb.BuildResult{}, err
+	} else if len(checks) > 0 {
+		label, err := NewLabel(HealthChecksLabel, checks)
+		if err != nil {
+			return libcnb.BuildResult{}, err
+		}
+		result.Labels = append(result.Labels, label)
+	}
+
 	return result, nil
 }
 
-func markDefaultProcess(result libcnb.BuildResult) {
+// secrets warns about the possible secrets in the commands of p, or fails, as configured by BP_PROCFILE_SECRETS.
+func (b Build) secrets(p Procfile) error {
+	mode := sherpa.GetEnvWithDefault("BP_PROCFILE_SECRETS", SecretsWarn)
+	if mode == SecretsIgnore {
+		return nil
+	} else if mode != SecretsWarn && mode != SecretsFail {
+		return fmt.Errorf("invalid BP_PROCFILE_SECRETS %q, must be one of %s, %s, %s", mode, SecretsWarn, SecretsFail, SecretsIgnore)
+	}
+
+	secrets, err := NewSecrets(p)
+	if err != nil {
+		return err
+	}
+
+	var types []string
+	for t := range secrets {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var found []string
+	for _, t := range types {
+		var rules []string
+		for _, s := range secrets[t] {
+			if !slices.Contains(rules, s.Rule) {
+				rules = append(rules, s.Rule)
+			}
+		}
+
+		if mode == SecretsWarn {
+			b.Logger.Headerf("Warning: process type %s contains possible secrets (%s), which are redacted in the output but contributed to the image",
+				t, strings.Join(rules, ", "))
+		}
+		found = append(found, fmt.Sprintf("%s (%s)", t, strings.Join(rules, ", ")))
+	}
+
+	if mode == SecretsFail && len(found) > 0 {
+		return fmt.Errorf("unable to contribute process types containing possible secrets, as configured by BP_PROCFILE_SECRETS: %s",
+			strings.Join(found, ", "))
+	}
+
+	return nil
+}
+
+// policy fails if processes violate the rules of the ProcfilePolicy bindings.
+func (b Build) policy(context libcnb.BuildContext, processes []libcnb.Process) error {
+	policy, err := NewPolicyFromBindings(context.Platform.Bindings)
+	if err != nil {
+		return err
+	} else if policy.Len() == 0 {
+		return nil
+	}
+
+	b.Logger.Headerf("Evaluating %d rules of %s bindings", policy.Len(), PolicyBindingType)
+
+	// Layers of all buildpacks share the parent directory of the layers of this buildpack
+	layers := ""
+	if context.Layers.Path != "" {
+		layers = filepath.Dir(context.Layers.Path)
+	}
+
+	var types []string
+	rules := map[string][]string{}
+	for _, v := range policy.Evaluate(processes, context.Application.Path, layers) {
+		if _, ok := rules[v.Type]; !ok {
+			types = append(types, v.Type)
+		}
+		rules[v.Type] = append(rules[v.Type], v.String())
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	var found []string
+	for _, t := range types {
+		found = append(found, fmt.Sprintf("%s (%s)", t, strings.Join(rules[t], ", ")))
+	}
+	return fmt.Errorf("unable to contribute process types violating policy rules: %s", strings.Join(found, ", "))
+}
+
+// profile creates a Profile evaluating the profile scripts of the application for the direct processes, if both exist.
+// Scripts that require a shell fail the build if no shell is available, and are otherwise left to shell processes.
+func (b Build) profile(context libcnb.BuildContext, processes []libcnb.Process, shell bool) (*Profile, error) {
+	var types []string
+	for _, p := range processes {
+		if p.Direct {
+			types = append(types, p.Type)
+		}
+	}
+	sort.Strings(types)
+
+	scripts, err := NewProfileScripts(context.Application.Path)
+	if err != nil {
+		return nil, err
+	} else if len(scripts) == 0 || len(types) == 0 {
+		return nil, nil
+	}
+
+	for _, f := range scripts {
+		if _, err := NewAssignments(f); err != nil {
+			var e ProfileError
+			if !errors.As(err, &e) {
+				return nil, err
+			} else if !shell {
+				return nil, fmt.Errorf("unable to evaluate profile scripts without a shell\n%w", err)
+			}
+
+			b.Logger.Headerf("Warning: %s, profile scripts are not evaluated for direct process types %s", e, strings.Join(types, ", "))
+			return nil, nil
+		}
+	}
+
+	p := NewProfile(context.Buildpack, types)
+	p.Logger = b.Logger
+	return &p, nil
+}
+
+// supervisor creates a Supervisor for the processes selected by BP_PROCFILE_SUPERVISOR_TYPES, all but hooks and
+// scheduled processes if not set. Selecting a hook is an error, as it would run both before and within the supervisor.
+func (b Build) supervisor(context libcnb.BuildContext, processes []libcnb.Process, hooks []Hook, schedules map[string]string) (Supervisor, error) {
+	selected := parseList(os.Getenv("BP_PROCFILE_SUPERVISOR_TYPES"))
+
+	var supervised []libcnb.Process
+	for _, p := range processes {
+		if p.Type == SupervisorProcessType {
+			return Supervisor{}, fmt.Errorf("unable to contribute supervisor, process type %s already exists", SupervisorProcessType)
+		}
+		hook := slices.ContainsFunc(hooks, func(h Hook) bool { return h.Type == p.Type })
+		if hook && slices.Contains(selected, p.Type) {
+			return Supervisor{}, fmt.Errorf("unable to supervise process type %s, it is a pre-start hook", p.Type)
+		}
+		_, scheduled := schedules[p.Type]
+		if (len(selected) == 0 && !hook && !scheduled) || slices.Contains(selected, p.Type) {
+			p.Default = false
+			supervised = append(supervised, p)
+		}
+	}
+
+	for _, t := range selected {
+		if !slices.ContainsFunc(supervised, func(p libcnb.Process) bool { return p.Type == t }) {
+			return Supervisor{}, fmt.Errorf("unable to supervise process type %s, it does not exist", t)
+		}
+	}
+
+	sort.Slice(supervised, func(i int, j int) bool {
+		return supervised[i].Type < supervised[j].Type
+	})
+
+	s := NewSupervisor(context.Buildpack, supervised)
+	s.Logger = b.Logger
+	return s, nil
+}
+
+// resolveBool returns the boolean value of the environment variable name, or def if it is not set.
+func resolveBool(name string, def bool) bool {
+	if _, ok := os.LookupEnv(name); !ok {
+		return def
+	}
+	return sherpa.ResolveBool(name)
+}
+
+func markDefaultProcess(result libcnb.BuildResult, schedules map[string]string) {
 	for _, magicType := range []string{"web", "worker"} {
 		for i, proc := range result.Processes {
+			if _, scheduled := schedules[proc.Type]; scheduled {
+				continue
+			}
 			if strings.EqualFold(magicType, proc.Type) {
 				result.Processes[i].Default = true
 				return
@@ -83,3 +420,18 @@ func markDefaultProcess(result libcnb.BuildResult) {
 		}
 	}
 }
+
+// supportsInterpolation indicates whether the launcher interpolates environment variables in direct processes of a
+// buildpack implementing api.
+func supportsInterpolation(api string) (bool, error) {
+	if api == "" {
+		return false, nil
+	}
+
+	v, err := semver.NewVersion(api)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse buildpack API %s\n%w", api, err)
+	}
+
+	return !v.LessThan(semver.MustParse(InterpolationAPI)), nil
+}
@@ -17,11 +17,16 @@
 package procfile_test
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/sclevine/spec"
 
 	"github.com/paketo-buildpacks/procfile/v5/procfile"
@@ -44,70 +49,916 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Entries: []libcnb.BuildpackPlanEntry{
 				{
 					Name: "procfile",
-					Metadata: map[string]interface{}{
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
 						"test-type-1": "test-command-1",
 						"test-type-2": "test-command-2 argument",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{
+				Type:    "test-type-1",
+				Command: "exec test-command-1",
+			},
+			libcnb.Process{
+				Type:    "test-type-2",
+				Command: "exec test-command-2 argument",
+			},
+		)
+
+		Expect(build.Build(ctx)).To(Equal(result))
+	})
+
+	it("logs the source of each process type", func() {
+		b := &bytes.Buffer{}
+		build.Logger = bard.NewLogger(b)
+		ctx.Application.Path = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: file-command"), 0644)).To(Succeed())
+		t.Setenv("BP_PROCFILE_DEFAULT_PROCESS", "env-command")
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web":    "file-command",
+						"worker": "worker-command",
+					}},
+				},
+			},
+		}
+
+		_, err := build.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(b.String()).To(MatchRegexp(`web\s+file\s+file-command`))
+		Expect(b.String()).To(MatchRegexp(`environment\s+env-command \(shadowed\)`))
+		Expect(b.String()).To(MatchRegexp(`worker\s+build plan\s+worker-command`))
+	})
+
+	it("removes process types filtered by BP_PROCFILE_INCLUDE_TYPES and BP_PROCFILE_EXCLUDE_TYPES", func() {
+		t.Setenv("BP_PROCFILE_INCLUDE_TYPES", "web,worker")
+		t.Setenv("BP_PROCFILE_EXCLUDE_TYPES", "worker")
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"clock":  "clock-command",
+						"web":    "web-command",
+						"worker": "worker-command",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{
+				Type:    "web",
+				Command: "exec web-command",
+				Default: true,
+			},
+		)
+
+		Expect(build.Build(ctx)).To(Equal(result))
+	})
+
+	it("logs process types prefixed with exec, leaving compound commands unchanged", func() {
+		b := &bytes.Buffer{}
+		build.Logger = bard.NewLogger(b)
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web":    "web-command",
+						"worker": "cd worker && worker-command",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{
+				Type:    "web",
+				Command: "exec web-command",
+				Default: true,
+			},
+			libcnb.Process{
+				Type:    "worker",
+				Command: "cd worker && worker-command",
+			},
+		)
+
+		Expect(build.Build(ctx)).To(Equal(result))
+		Expect(b.String()).To(ContainSubstring("Prefixing commands of process types web with exec, so that they receive signals"))
+	})
+
+	it("does not prefix commands with exec given BP_PROCFILE_EXEC=false", func() {
+		t.Setenv("BP_PROCFILE_EXEC", "false")
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web": "web-command",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{
+				Type:    "web",
+				Command: "web-command",
+				Default: true,
+			},
+		)
+
+		Expect(build.Build(ctx)).To(Equal(result))
+	})
+
+	context("given BP_PROCFILE_RESCAN=true", func() {
+		var b *bytes.Buffer
+
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_RESCAN", "true")
+			b = &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Application.Path = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: generated-command\nworker: generated-worker-command"), 0644)).To(Succeed())
+		})
+
+		it("adds process types from a Procfile generated during build", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"web": "detected-command",
+						}},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{
+					Type:    "web",
+					Command: "exec detected-command",
+					Default: true,
+				},
+				libcnb.Process{
+					Type:    "worker",
+					Command: "exec generated-worker-command",
+				},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Discovered process types worker during build"))
+		})
+
+		it("adds process types from a Procfile generated during build without Procfile during detection", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{
+							"processes": map[string]interface{}{"web": "contributed-command"},
+						},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{
+					Type:    "web",
+					Command: "exec generated-command",
+					Default: true,
+				},
+				libcnb.Process{
+					Type:    "worker",
+					Command: "exec generated-worker-command",
+				},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Discovered process types web, worker during build"))
+		})
+
+		it("adds process types from a Procfile generated during build without any process types during detection", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{}}},
+				},
+			}
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Processes).To(HaveLen(2))
+			Expect(ctx.Plan.Entries[0].Metadata).To(Equal(map[string]interface{}{"application": map[string]interface{}{}}))
+		})
+	})
+
+	it("ignores a Procfile generated during build by default", func() {
+		ctx.Application.Path = t.TempDir()
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("worker: generated-worker-command"), 0644)).To(Succeed())
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web": "detected-command",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{
+				Type:    "web",
+				Command: "exec detected-command",
+				Default: true,
+			},
+		)
+
+		Expect(build.Build(ctx)).To(Equal(result))
+	})
+
+	context("given process types contributed by other buildpacks", func() {
+		var contributions []libcnb.BuildpackPlanEntry
+
+		it.Before(func() {
+			contributions = []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{
+						"buildpack": "test/language",
+						"processes": map[string]interface{}{
+							"web":  "language-web-command",
+							"task": "language-task-command",
+						},
+					},
+				},
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{
+						"buildpack": "test/framework",
+						"processes": map[string]interface{}{
+							"task": "framework-task-command",
+						},
+					},
+				},
+			}
+		})
+
+		it("adds contributed process types without Procfile, later buildpacks taking precedence", func() {
+			ctx.Plan = libcnb.BuildpackPlan{Entries: contributions}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{
+					Type:    "task",
+					Command: "exec framework-task-command",
+				},
+				libcnb.Process{
+					Type:    "web",
+					Command: "exec language-web-command",
+					Default: true,
+				},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("merges contributed process types with the Procfile, Procfile taking precedence", func() {
+			ctx.Plan = libcnb.BuildpackPlan{Entries: append(contributions, libcnb.BuildpackPlanEntry{
+				Name: "procfile",
+				Metadata: map[string]interface{}{"application": map[string]interface{}{
+					"web": "app-web-command",
+				}},
+			})}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{
+					Type:    "task",
+					Command: "exec framework-task-command",
+				},
+				libcnb.Process{
+					Type:    "web",
+					Command: "exec app-web-command",
+					Default: true,
+				},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("logs conflicts between contributing buildpacks", func() {
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Plan = libcnb.BuildpackPlan{Entries: contributions}
+
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(b.String()).To(ContainSubstring("Process type task contributed by buildpack:test/language conflicts with buildpack:test/framework, using buildpack:test/framework"))
+			Expect(b.String()).To(MatchRegexp(`task\s+buildpack:test/framework\s+framework-task-command`))
+		})
+
+		it("fails with invalid contributions", func() {
+			contributions[0].Metadata["processes"] = map[string]interface{}{"web": 1}
+			ctx.Plan = libcnb.BuildpackPlan{Entries: contributions}
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("invalid command for process type web contributed by buildpack:test/language, must be a string")))
+		})
+	})
+
+	context("given BP_PROCFILE_SUPERVISOR=true", func() {
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_SUPERVISOR", "true")
+			ctx.Layers.Path = t.TempDir()
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"clock":  "clock-command",
+							"web":    "web-command",
+							"worker": "worker-command",
+						}},
+					},
+				},
+			}
+		})
+
+		it("adds a process supervising the selected process types", func() {
+			t.Setenv("BP_PROCFILE_SUPERVISOR_TYPES", "web,worker")
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].(procfile.Supervisor).Processes).To(Equal([]libcnb.Process{
+				{Type: "web", Command: "exec web-command"},
+				{Type: "worker", Command: "exec worker-command"},
+			}))
+			Expect(result.Processes).To(ContainElement(libcnb.Process{
+				Type:      "all",
+				Command:   filepath.Join(ctx.Layers.Path, "supervisor", "bin", "supervisor"),
+				Arguments: []string{filepath.Join(ctx.Layers.Path, "supervisor", "processes.toml")},
+				Direct:    true,
+			}))
+			Expect(result.Processes).To(ContainElement(libcnb.Process{Type: "web", Command: "exec web-command", Default: true}))
+		})
+
+		it("supervises all process types by default", func() {
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].(procfile.Supervisor).Processes).To(HaveLen(3))
+		})
+
+		it("fails with an unknown selected process type", func() {
+			t.Setenv("BP_PROCFILE_SUPERVISOR_TYPES", "web,release")
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError("unable to supervise process type release, it does not exist"))
+		})
+	})
+
+	context("given BP_DIRECT_PROCESS=true", func() {
+		it.Before(func() {
+			t.Setenv("BP_DIRECT_PROCESS", "true")
+		})
+
+		it("uses a process with direct=true", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"test-type": "test-command arg",
+						}},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{
+					Type:      "test-type",
+					Command:   "test-command",
+					Arguments: []string{"arg"},
+					Direct:    true,
+				},
+			)
+
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Executing processes directly, as configured by BP_DIRECT_PROCESS"))
+		})
+	})
+
+	context("given per-process execution overrides", func() {
+		it.Before(func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"web":    map[string]interface{}{"command": "web-command arg", "direct": true},
+							"worker": map[string]interface{}{"command": "worker-command", "direct": false},
+						}},
+					},
+				},
+			}
+		})
+
+		it("executes each process type as configured", func() {
+			t.Setenv("BP_DIRECT_PROCESS", "true")
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "web-command", Arguments: []string{"arg"}, Direct: true, Default: true},
+				libcnb.Process{Type: "worker", Command: "exec worker-command"},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("returns error if shell execution is requested on a tiny stack", func() {
+			ctx.StackID = libpak.JammyTinyStackID
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError("unable to execute process type worker within a shell, the stack does not provide one"))
+		})
+	})
+
+	context("given BP_PROCFILE_SHELL", func() {
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_SHELL", "/bin/sh")
+		})
+
+		it("executes shell processes directly with the configured shell", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"web":    "web-command",
+							"worker": map[string]interface{}{"command": "worker-command arg", "direct": true},
+						}},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "/bin/sh", Arguments: []string{"-c", "exec web-command"}, Direct: true, Default: true},
+				libcnb.Process{Type: "worker", Command: "worker-command", Arguments: []string{"arg"}, Direct: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("warns about bash-only syntax with a POSIX shell", func() {
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "source .env && web-command"}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.String()).To(ContainSubstring("Warning: process type web uses bash-only syntax (source), which /bin/sh might not support"))
+		})
+
+		it("allows shell processes on a tiny stack", func() {
+			ctx.StackID = libpak.JammyTinyStackID
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": map[string]interface{}{"command": "web-command", "direct": false}}},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "/bin/sh", Arguments: []string{"-c", "exec web-command"}, Direct: true, Default: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+	})
+
+	context("direct processes referencing environment variables", func() {
+		it.Before(func() {
+			t.Setenv("BP_DIRECT_PROCESS", "true")
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server --port ${PORT}"}},
+					},
+				},
+			}
+		})
+
+		it("translates references for the launcher", func() {
+			ctx.Buildpack.API = "0.9"
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "./server", Arguments: []string{"--port", "$(PORT)"}, Direct: true, Default: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("returns error with unsupported references", func() {
+			ctx.Buildpack.API = "0.9"
+			ctx.Plan.Entries[0].Metadata["application"].(map[string]interface{})["web"] = "./server --port ${PORT:-8080}"
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to interpolate process type web")))
+		})
+
+		it("passes references unchanged with a warning before Buildpack API 0.9", func() {
+			ctx.Buildpack.API = "0.7"
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "./server", Arguments: []string{"--port", "${PORT}"}, Direct: true, Default: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Warning: process type web references environment variables PORT"))
+		})
+
+		it("executes the process within a shell", func() {
+			ctx.Plan.Entries[0].Metadata["application"].(map[string]interface{})["web"] = map[string]interface{}{"command": "./server --port ${PORT}", "direct": false}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "web", Command: "exec ./server --port ${PORT}", Default: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+	})
+
+	context("given BP_PROCFILE_SUBSTITUTE=true", func() {
+		it("redacts values from bindings in the build log", func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Platform.Bindings = libcnb.Bindings{
+				{Name: "variables", Type: "ProcfileVariables", Secret: map[string]string{"BP_TOKEN": "s3cr3t"}},
+			}
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server --token s3cr3t"}},
 					},
 				},
-			},
-		}
+			}
 
-		result := libcnb.NewBuildResult()
-		result.Processes = append(result.Processes,
-			libcnb.Process{
-				Type:    "test-type-1",
-				Command: "test-command-1",
-			},
-			libcnb.Process{
-				Type:    "test-type-2",
-				Command: "test-command-2 argument",
-			},
-		)
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.String()).To(ContainSubstring("./server --token [REDACTED]"))
+			Expect(b.String()).NotTo(ContainSubstring("s3cr3t"))
+		})
 
-		Expect(build.Build(ctx)).To(Equal(result))
+		it("redacts values from bindings in errors", func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			t.Setenv("BP_DIRECT_PROCESS", "true")
+			ctx.Platform.Bindings = libcnb.Bindings{
+				{Name: "variables", Type: "ProcfileVariables", Secret: map[string]string{"BP_TOKEN": "s3cr3t"}},
+			}
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server --token s3cr3t 'unterminated"}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("./server --token [REDACTED]")))
+			Expect(err.Error()).NotTo(ContainSubstring("s3cr3t"))
+		})
+
+		it("does not log substitutions of the reported sources again", func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			t.Setenv("BP_NAME", "server")
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Application.Path = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: ./${BP_NAME}"), 0644)).To(Succeed())
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server"}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.String()).To(MatchRegexp(`web\s+file\s+./server`))
+			Expect(b.String()).NotTo(ContainSubstring("Substituting placeholders"))
+		})
+
+		it("warns about placeholders of the reported sources that cannot be substituted", func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Application.Path = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: ./${BP_NAME}"), 0644)).To(Succeed())
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server"}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.String()).To(ContainSubstring("Warning: unable to substitute placeholders of Procfile sources, reporting them unchanged"))
+		})
+
+		it("returns error with placeholders that cannot be substituted when rescanning", func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			t.Setenv("BP_PROCFILE_RESCAN", "true")
+			ctx.Application.Path = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: ./${BP_NAME}"), 0644)).To(Succeed())
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server"}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to substitute process type web from file")))
+		})
 	})
 
-	context("given BP_DIRECT_PROCESS=true", func() {
+	context("given profile scripts", func() {
 		it.Before(func() {
-			t.Setenv("BP_DIRECT_PROCESS", "true")
+			ctx.Application.Path = t.TempDir()
+			ctx.Buildpack.Path = t.TempDir()
+			ctx.Layers.Path = t.TempDir()
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"web":    map[string]interface{}{"command": "web-command", "direct": true},
+							"worker": "worker-command",
+						}},
+					},
+				},
+			}
 		})
 
-		it("uses a process with direct=true", func() {
+		it("contributes the profile helper for direct processes", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".profile"), []byte("export APP_ENV=production"), 0644)).To(Succeed())
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Name()).To(Equal("profile"))
+			Expect(result.Layers[0].(procfile.Profile).Types).To(Equal([]string{"web"}))
+		})
+
+		it("warns about scripts requiring a shell", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".profile"), []byte("source .env"), 0644)).To(Succeed())
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(BeEmpty())
+			Expect(b.String()).To(ContainSubstring("profile scripts are not evaluated for direct process types web"))
+		})
+
+		it("returns error for scripts requiring a shell without a shell", func() {
+			ctx.StackID = libpak.JammyTinyStackID
+			ctx.Plan.Entries[0].Metadata = map[string]interface{}{"application": map[string]interface{}{"web": "web-command"}}
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".profile"), []byte("export A=b\nsource .env"), 0644)).To(Succeed())
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(fmt.Sprintf("unable to evaluate profile scripts without a shell\n%s:2 requires a shell to evaluate \"source .env\", only export NAME=value lines are supported",
+				filepath.Join(ctx.Application.Path, ".profile"))))
+		})
+	})
+
+	context("given pre-start hooks", func() {
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_PRE_START", "release")
 			ctx.Plan = libcnb.BuildpackPlan{
 				Entries: []libcnb.BuildpackPlanEntry{
 					{
 						Name: "procfile",
-						Metadata: map[string]interface{}{
-							"test-type": "test-command arg",
-						},
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"release": "rake db:migrate",
+							"web":     "web-command",
+							"worker":  "worker-command",
+						}},
 					},
 				},
 			}
+		})
 
-			result := libcnb.NewBuildResult()
-			result.Processes = append(result.Processes,
-				libcnb.Process{
-					Type:      "test-type",
-					Command:   "test-command",
-					Arguments: []string{"arg"},
-					Direct:    true,
-				},
-			)
+		it("contributes hooks running before other process types", func() {
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
 
-			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].(procfile.Hooks).Hooks).To(Equal([]procfile.Hook{
+				{Type: "release", Command: "exec rake db:migrate", OnFailure: "fail", Types: []string{"web", "worker"}},
+			}))
+		})
+
+		it("runs hooks before the supervisor instead of supervising them", func() {
+			t.Setenv("BP_PROCFILE_SUPERVISOR", "true")
+
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(result.Layers[0].(procfile.Supervisor).Processes).To(HaveLen(2))
+			Expect(result.Layers[1].(procfile.Hooks).Hooks[0].Types).To(Equal([]string{"all", "web", "worker"}))
+		})
+
+		it("returns error when supervising hooks", func() {
+			t.Setenv("BP_PROCFILE_SUPERVISOR", "true")
+			t.Setenv("BP_PROCFILE_SUPERVISOR_TYPES", "release,web")
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError("unable to supervise process type release, it is a pre-start hook"))
 		})
 	})
 
+	it("adds a label describing ports", func() {
+		b := &bytes.Buffer{}
+		build.Logger = bard.NewLogger(b)
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"admin":  map[string]interface{}{"command": "admin-command", "port": int64(9000)},
+						"web":    "./server --port 8080",
+						"worker": "worker-command",
+					}},
+				},
+			},
+		}
+
+		result, err := build.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Labels).To(Equal([]libcnb.Label{
+			{Key: "io.paketo.procfile.ports", Value: `{"admin":9000,"web":8080}`},
+		}))
+		Expect(b.String()).To(ContainSubstring("Inferred ports of process types web from their commands"))
+	})
+
+	it("adds a label describing formations", func() {
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web": map[string]interface{}{
+							"command":   "web-command",
+							"formation": map[string]interface{}{"replicas": int64(2), "cpu": "500m", "memory": "512Mi"},
+						},
+						"worker": "worker-command",
+					}},
+				},
+			},
+		}
+
+		result, err := build.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Labels).To(Equal([]libcnb.Label{
+			{Key: "io.paketo.procfile.formation", Value: `{"web":{"replicas":2,"cpu":"500m","memory":"512Mi"}}`},
+		}))
+	})
+
+	it("adds a label describing schedules and does not mark scheduled processes as default", func() {
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web":    map[string]interface{}{"command": "report-command", "schedule": "@daily"},
+						"worker": "worker-command",
+					}},
+				},
+			},
+		}
+
+		result := libcnb.NewBuildResult()
+		result.Processes = append(result.Processes,
+			libcnb.Process{Type: "web", Command: "exec report-command"},
+			libcnb.Process{Type: "worker", Command: "exec worker-command", Default: true},
+		)
+		result.Labels = append(result.Labels, libcnb.Label{Key: "io.paketo.procfile.schedules", Value: `{"web":"@daily"}`})
+
+		Expect(build.Build(ctx)).To(Equal(result))
+	})
+
+	it("does not supervise scheduled processes", func() {
+		t.Setenv("BP_PROCFILE_SUPERVISOR", "true")
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"clock": map[string]interface{}{"command": "clock-command", "schedule": "*/5 * * * *"},
+						"web":   "web-command",
+					}},
+				},
+			},
+		}
+
+		result, err := build.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Layers[0].(procfile.Supervisor).Processes).To(ConsistOf(HaveField("Type", "web")))
+	})
+
+	it("adds a label describing health checks", func() {
+		ctx.Plan = libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{
+					Name: "procfile",
+					Metadata: map[string]interface{}{"application": map[string]interface{}{
+						"web": map[string]interface{}{
+							"command":      "web-command",
+							"health-check": map[string]interface{}{"path": "/health", "port": int64(8080), "interval": "10s"},
+						},
+						"worker": map[string]interface{}{
+							"command":      "worker-command",
+							"health-check": map[string]interface{}{"command": "./bin/check"},
+						},
+					}},
+				},
+			},
+		}
+
+		result, err := build.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Labels).To(Equal([]libcnb.Label{
+			{Key: "io.paketo.procfile.health-checks", Value: `{"web":{"path":"/health","port":8080,"interval":"10s"},"worker":{"command":"./bin/check"}}`},
+		}))
+	})
+
 	context("given a special process name", func() {
 		var assertMarkedAsDefault = func(name string) {
 			ctx.Plan = libcnb.BuildpackPlan{
 				Entries: []libcnb.BuildpackPlanEntry{
 					{
 						Name: "procfile",
-						Metadata: map[string]interface{}{
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
 							"test-type-1": "test-command-1",
 							name:          "test-command-2 argument",
-						},
+						}},
 					},
 				},
 			}
@@ -116,11 +967,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			result.Processes = append(result.Processes,
 				libcnb.Process{
 					Type:    "test-type-1",
-					Command: "test-command-1",
+					Command: "exec test-command-1",
 				},
 				libcnb.Process{
 					Type:    name,
-					Command: "test-command-2 argument",
+					Command: "exec test-command-2 argument",
 					Default: true,
 				},
 			)
@@ -142,10 +993,10 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 					Entries: []libcnb.BuildpackPlanEntry{
 						{
 							Name: "procfile",
-							Metadata: map[string]interface{}{
+							Metadata: map[string]interface{}{"application": map[string]interface{}{
 								"web":    "test-command-1",
 								"worker": "test-command-2 argument",
-							},
+							}},
 						},
 					},
 				}
@@ -154,12 +1005,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				result.Processes = append(result.Processes,
 					libcnb.Process{
 						Type:    "web",
-						Command: "test-command-1",
+						Command: "exec test-command-1",
 						Default: true,
 					},
 					libcnb.Process{
 						Type:    "worker",
-						Command: "test-command-2 argument",
+						Command: "exec test-command-2 argument",
 					},
 				)
 
@@ -178,10 +1029,10 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Entries: []libcnb.BuildpackPlanEntry{
 					{
 						Name: "procfile",
-						Metadata: map[string]interface{}{
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
 							"test-type-1": "test-command-1",
 							"test-type-2": "test-command-2 argument",
-						},
+						}},
 					},
 				},
 			}
@@ -217,10 +1068,10 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Entries: []libcnb.BuildpackPlanEntry{
 					{
 						Name: "procfile",
-						Metadata: map[string]interface{}{
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
 							"test-type-1": "test-command-1",
 							"test-type-2": "test-command-2 argument",
-						},
+						}},
 					},
 				},
 			}
@@ -244,4 +1095,178 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(build.Build(ctx)).To(Equal(result))
 		})
 	})
+
+	context("run image distribution without a shell", func() {
+		it.Before(func() {
+			t.Setenv("CNB_TARGET_DISTRO_NAME", "distroless")
+			t.Setenv("CNB_TARGET_DISTRO_VERSION", "12")
+		})
+
+		it("uses direct processes and logs the reason", func() {
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name:     "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{"test-type": "test-command argument"}},
+					},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes,
+				libcnb.Process{Type: "test-type", Command: "test-command", Arguments: []string{"argument"}, Direct: true},
+			)
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Executing processes directly, the run image distribution distroless 12 does not provide a shell"))
+		})
+	})
+
+	context("commands containing secrets", func() {
+		var b *bytes.Buffer
+
+		it.Before(func() {
+			b = &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"web":    "./server --db postgres://app:hunter2@db/app",
+							"worker": "./worker",
+						}},
+					},
+				},
+			}
+		})
+
+		it("warns and redacts secrets in the build log", func() {
+			result, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Processes).To(ContainElement(HaveField("Command", "exec ./server --db postgres://app:hunter2@db/app")))
+
+			Expect(b.String()).To(ContainSubstring("Warning: process type web contains possible secrets (URL with password), which are redacted in the output but contributed to the image"))
+			Expect(b.String()).To(ContainSubstring("postgres://app:[REDACTED]@db/app"))
+			Expect(b.String()).NotTo(ContainSubstring("hunter2"))
+		})
+
+		it("fails with BP_PROCFILE_SECRETS=fail", func() {
+			t.Setenv("BP_PROCFILE_SECRETS", "fail")
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError("unable to contribute process types containing possible secrets, as configured by BP_PROCFILE_SECRETS: web (URL with password)"))
+		})
+
+		it("does not warn with BP_PROCFILE_SECRETS=ignore", func() {
+			t.Setenv("BP_PROCFILE_SECRETS", "ignore")
+
+			_, err := build.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.String()).NotTo(ContainSubstring("Warning"))
+		})
+
+		it("redacts secrets in errors", func() {
+			t.Setenv("BP_DIRECT_PROCESS", "true")
+			ctx.Plan.Entries[0].Metadata["application"].(map[string]interface{})["web"] = "./server --password=hunter2 --name 'unterminated"
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to parse ./server")))
+			Expect(err.Error()).To(ContainSubstring("--password=[REDACTED]"))
+			Expect(err.Error()).NotTo(ContainSubstring("hunter2"))
+		})
+
+		it("returns error for invalid BP_PROCFILE_SECRETS", func() {
+			t.Setenv("BP_PROCFILE_SECRETS", "block")
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(`invalid BP_PROCFILE_SECRETS "block", must be one of warn, fail, ignore`))
+		})
+	})
+
+	context("ProcfilePolicy binding", func() {
+		it.Before(func() {
+			ctx.Application.Path = "/workspace"
+			ctx.Platform.Bindings = libcnb.Bindings{
+				{
+					Name: "policy",
+					Type: "ProcfilePolicy",
+					Secret: map[string]string{"policy.toml": `
+[[allow]]
+name = "trusted-executables"
+executable = '^/(workspace|layers)/'
+
+[[deny]]
+name = "curl-pipe-sh"
+command = 'curl[^|]*\|\s*(ba)?sh'
+`},
+				},
+			}
+		})
+
+		it("contributes processes complying with the policy", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "./server"}}},
+				},
+			}
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes, libcnb.Process{Type: "web", Command: "exec ./server", Default: true})
+
+			Expect(build.Build(ctx)).To(Equal(result))
+		})
+
+		it("returns error listing violations by process type and rule", func() {
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{
+						Name: "procfile",
+						Metadata: map[string]interface{}{"application": map[string]interface{}{
+							"setup":  "curl -s https://example.com/install | sh",
+							"web":    "./server",
+							"worker": "/usr/bin/worker",
+						}},
+					},
+				},
+			}
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError("unable to contribute process types violating policy rules: " +
+				"setup (allow rule trusted-executables, deny rule curl-pipe-sh), worker (allow rule trusted-executables)"))
+		})
+	})
+
+	context("unreadable Procfile sources", func() {
+		it.Before(func() {
+			ctx.Platform.Bindings = libcnb.Bindings{
+				{Name: "procfile", Type: "Procfile", Secret: map[string]string{"Procfile": "web: binding-command", "priority": "high"}},
+			}
+			ctx.Plan = libcnb.BuildpackPlan{
+				Entries: []libcnb.BuildpackPlanEntry{
+					{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "test-command"}}},
+				},
+			}
+		})
+
+		it("warns and reports process types from the build plan", func() {
+			b := &bytes.Buffer{}
+			build.Logger = bard.NewLogger(b)
+
+			result := libcnb.NewBuildResult()
+			result.Processes = append(result.Processes, libcnb.Process{Type: "web", Command: "exec test-command", Default: true})
+
+			Expect(build.Build(ctx)).To(Equal(result))
+			Expect(b.String()).To(ContainSubstring("Warning: unable to read Procfile sources, reporting process types as passed through the build plan: unable to parse priority of binding procfile"))
+		})
+
+		it("returns error when rescanning", func() {
+			t.Setenv("BP_PROCFILE_RESCAN", "true")
+
+			_, err := build.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to read Procfile sources\nunable to parse priority of binding procfile")))
+		})
+	})
 }
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// StructuredFile is the name of the file declaring structured definitions of process types, next to a Procfile.
+const StructuredFile = "Procfile.toml"
+
+// Definition is the structured definition of a process type. A Procfile entry is either a command, or a table of the
+// attributes of a Definition.
+type Definition struct {
+
+	// Command is the command of the process type.
+	Command string `toml:"command"`
+
+	// Direct overrides whether the process type is executed directly or within a shell, if set.
+	Direct *bool `toml:"direct"`
+
+	// PreStart declares the process type as a hook running before other process types start, if set.
+	PreStart *PreStart `toml:"pre-start"`
+
+	// HealthCheck describes how to check the health of the process type, if set.
+	HealthCheck *HealthCheck `toml:"health-check"`
+
+	// Port is the port the process type listens on, inferred from its command if not set.
+	Port int `toml:"port"`
+
+	// Formation describes the scaling and resource hints of the process type, if set.
+	Formation *Formation `toml:"formation"`
+
+	// Schedule is the cron expression describing when the process type runs, if it is a scheduled job.
+	Schedule string `toml:"schedule"`
+}
+
+// Validate returns an error if attributes of the definition contain invalid values.
+func (d Definition) Validate() error {
+	if d.Port != 0 && !validPort(d.Port) {
+		return fmt.Errorf("port %d must be between 1 and 65535", d.Port)
+	}
+
+	if d.HealthCheck != nil {
+		if err := d.HealthCheck.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if d.Formation != nil {
+		if err := d.Formation.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if d.Schedule != "" {
+		if err := validateSchedule(d.Schedule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PreStart describes when a pre-start hook runs.
+type PreStart struct {
+
+	// Types are the process types the hook runs before, all other process types if empty.
+	Types []string `toml:"types"`
+
+	// OnFailure is the behavior when the hook fails, OnFailureFail if empty.
+	OnFailure string `toml:"on-failure"`
+}
+
+// NewDefinition creates a Definition from an entry of a Procfile.
+func NewDefinition(v interface{}) (Definition, error) {
+	switch v := v.(type) {
+	case string:
+		return Definition{Command: v}, nil
+	case map[string]interface{}:
+		b, err := toml.Marshal(v)
+		if err != nil {
+			return Definition{}, fmt.Errorf("unable to encode %v\n%w", v, err)
+		}
+
+		var d Definition
+		md, err := toml.Decode(string(b), &d)
+		if err != nil {
+			return Definition{}, fmt.Errorf("unable to decode %v\n%w", v, err)
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			var keys []string
+			for _, k := range undecoded {
+				keys = append(keys, k.String())
+			}
+			sort.Strings(keys)
+			return Definition{}, fmt.Errorf("unknown keys %s", strings.Join(keys, ", "))
+		}
+
+		if err := d.Validate(); err != nil {
+			return Definition{}, err
+		}
+
+		return d, nil
+	default:
+		return Definition{}, fmt.Errorf("invalid entry %v, must be a command or a table", v)
+	}
+}
+
+// newProcfileFromStructuredFile reads the structured definitions of process types from f if it exists. Every table of
+// f is the Definition of the process type it is named after.
+func newProcfileFromStructuredFile(f string) (Procfile, error) {
+	raw := map[string]interface{}{}
+	if _, err := toml.DecodeFile(f, &raw); err != nil && os.IsNotExist(err) {
+		return Procfile{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to decode %s\n%w", f, err)
+	}
+
+	p := Procfile{}
+	for k, v := range raw {
+		t, ok := v.(map[string]interface{})
+		if !ok || !processType.MatchString(k) {
+			return nil, fmt.Errorf("invalid process type %s in %s, must be a table named after the process type", k, f)
+		}
+
+		if _, err := NewDefinition(t); err != nil {
+			return nil, fmt.Errorf("invalid process type %s in %s\n%w", k, f, err)
+		}
+
+		p[k] = t
+	}
+
+	return p, nil
+}
+
+// command returns the command of a Procfile entry, or an empty string if it has none.
+func command(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		c, _ := v["command"].(string)
+		return c
+	default:
+		d, _ := NewDefinition(v)
+		return d.Command
+	}
+}
+
+// mergeEntries merges the Procfile entry higher into lower. Attributes declared by higher take precedence, the others
+// are kept from lower.
+func mergeEntries(lower interface{}, higher interface{}) interface{} {
+	l, lOK := lower.(map[string]interface{})
+	h, hOK := higher.(map[string]interface{})
+
+	switch {
+	case !hOK && !lOK:
+		return higher
+	case !hOK:
+		m := maps.Clone(l)
+		m["command"] = higher
+		return m
+	default:
+		m := map[string]interface{}{}
+		if lOK {
+			maps.Copy(m, l)
+		} else if c := command(lower); c != "" {
+			m["command"] = c
+		}
+		maps.Copy(m, h)
+		return m
+	}
+}
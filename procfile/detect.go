@@ -21,34 +21,83 @@ import (
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
 )
 
 type Detect struct{}
 
 func (d Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error) {
 	l := bard.NewLogger(os.Stdout)
-	// Create Procfile from source path or binding, if both exist, merge into one. The binding takes precedence on duplicate name/command pairs.
-	p, err := NewProcfileFromEnvironmentOrPathOrBinding(context.Application.Path, context.Platform.Bindings)
+	// Create Procfile from environment, source path or binding, if several exist, merge into one. Duplicate name/command pairs are resolved using BP_PROCFILE_PRECEDENCE.
+	sources, err := NewSources(context.Application.Path, context.Platform.Bindings)
 	if err != nil {
 		return libcnb.DetectResult{}, err
 	}
 
-	if len(p) == 0 {
-		l.Logger.Info("SKIPPED: No procfile found from environment, source path, or binding.")
-		return libcnb.DetectResult{Pass: false}, nil
+	variables := NewVariables(context.Platform.Bindings)
+	if sherpa.ResolveBool("BP_PROCFILE_SUBSTITUTE") {
+		if sources, err = variables.SubstituteSources(sources, l); err != nil {
+			return libcnb.DetectResult{}, err
+		}
 	}
+	p, report := Merge(sources...)
 
-	return libcnb.DetectResult{
-		Pass: true,
-		Plans: []libcnb.BuildPlan{
-			{
-				Provides: []libcnb.BuildPlanProvide{
-					{Name: "procfile"},
+	// Without a Procfile, provide procfile so that other buildpacks can require it with process types of their own.
+	// Require it too when rescanning, so that the build reads a Procfile generated by an earlier buildpack.
+	if len(p) == 0 && sherpa.ResolveBool("BP_PROCFILE_RESCAN") {
+		l.Logger.Info("No procfile found from environment, source path, or binding, requiring procfile to read a Procfile generated during build, as configured by BP_PROCFILE_RESCAN.")
+		return libcnb.DetectResult{
+			Pass: true,
+			Plans: []libcnb.BuildPlan{
+				{
+					Provides: []libcnb.BuildPlanProvide{
+						{Name: PlanEntryName},
+					},
+					Requires: []libcnb.BuildPlanRequire{
+						{Name: PlanEntryName, Metadata: map[string]interface{}{PlanApplication: Procfile{}}},
+					},
 				},
-				Requires: []libcnb.BuildPlanRequire{
-					{Name: "procfile", Metadata: p},
+			},
+		}, nil
+	} else if len(p) == 0 {
+		l.Logger.Info("No procfile found from environment, source path, or binding, providing procfile for other buildpacks.")
+		return libcnb.DetectResult{
+			Pass: true,
+			Plans: []libcnb.BuildPlan{
+				{
+					Provides: []libcnb.BuildPlanProvide{
+						{Name: PlanEntryName},
+					},
 				},
 			},
+		}, nil
+	}
+
+	report.Redact(variables.Redact).Redact(RedactSecrets).Log(l)
+
+	plan := libcnb.BuildPlan{
+		Provides: []libcnb.BuildPlanProvide{
+			{Name: PlanEntryName},
 		},
-	}, nil
+		Requires: []libcnb.BuildPlanRequire{
+			{Name: PlanEntryName, Metadata: map[string]interface{}{PlanApplication: p}},
+		},
+	}
+
+	checks, err := NewHealthChecks(p)
+	if err != nil {
+		return libcnb.DetectResult{}, err
+	} else if len(checks) == 0 {
+		return libcnb.DetectResult{Pass: true, Plans: []libcnb.BuildPlan{plan}}, nil
+	}
+
+	// Configure the health-checker buildpack if it is part of the group, without requiring it
+	withHealthChecker := libcnb.BuildPlan{
+		Provides: plan.Provides,
+		Requires: append([]libcnb.BuildPlanRequire{
+			{Name: HealthCheckerPlanName, Metadata: map[string]interface{}{"launch": true, "health-checks": checks}},
+		}, plan.Requires...),
+	}
+
+	return libcnb.DetectResult{Pass: true, Plans: []libcnb.BuildPlan{withHealthChecker, plan}}, nil
 }
@@ -44,21 +44,48 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		Expect(os.RemoveAll(ctx.Application.Path)).To(Succeed())
 	})
 
-	it("fails without Procfile or BP_PROCFILE_DEFAULT_PROCESS", func() {
-		Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{}))
+	var provideOnly = libcnb.DetectResult{
+		Pass: true,
+		Plans: []libcnb.BuildPlan{
+			{
+				Provides: []libcnb.BuildPlanProvide{
+					{Name: "procfile"},
+				},
+			},
+		},
+	}
+
+	it("provides procfile without requiring it without Procfile or BP_PROCFILE_DEFAULT_PROCESS", func() {
+		Expect(detect.Detect(ctx)).To(Equal(provideOnly))
 	})
 
-	it("fails with empty Procfile", func() {
+	it("provides procfile without requiring it with empty Procfile", func() {
 		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte(""), 0644))
 
-		Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{}))
+		Expect(detect.Detect(ctx)).To(Equal(provideOnly))
 	})
 
-	it("fails with empty Procfile and empty BP_PROCFILE_DEFAULT_PROCESS", func() {
+	it("provides procfile without requiring it with empty Procfile and empty BP_PROCFILE_DEFAULT_PROCESS", func() {
 		t.Setenv("BP_PROCFILE_DEFAULT_PROCESS", "")
 		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte(""), 0644))
 
-		Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{}))
+		Expect(detect.Detect(ctx)).To(Equal(provideOnly))
+	})
+
+	it("provides and requires procfile without Procfile with BP_PROCFILE_RESCAN", func() {
+		t.Setenv("BP_PROCFILE_RESCAN", "true")
+
+		Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{
+			Pass: true,
+			Plans: []libcnb.BuildPlan{
+				{
+					Provides: []libcnb.BuildPlanProvide{{Name: "procfile"}},
+					Requires: []libcnb.BuildPlanRequire{
+						{Name: "procfile", Metadata: map[string]interface{}{"application": procfile.Procfile{}}},
+					},
+				},
+			},
+		}))
 	})
 
 	it("passes with Procfile", func() {
@@ -73,10 +100,10 @@ test-type-2: test-command-2`), 0644))
 						{Name: "procfile"},
 					},
 					Requires: []libcnb.BuildPlanRequire{
-						{Name: "procfile", Metadata: procfile.Procfile{
+						{Name: "procfile", Metadata: map[string]interface{}{"application": procfile.Procfile{
 							"test-type-1": "test-command-1",
 							"test-type-2": "test-command-2",
-						}},
+						}}},
 					},
 				},
 			},
@@ -94,9 +121,76 @@ test-type-2: test-command-2`), 0644))
 						{Name: "procfile"},
 					},
 					Requires: []libcnb.BuildPlanRequire{
-						{Name: "procfile", Metadata: procfile.Procfile{
+						{Name: "procfile", Metadata: map[string]interface{}{"application": procfile.Procfile{
 							"web": "test-command-1",
+						}}},
+					},
+				},
+			},
+		}))
+	})
+
+	context("given BP_PROCFILE_SUBSTITUTE=true", func() {
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_SUBSTITUTE", "true")
+			t.Setenv("BP_ENV", "production")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: ./server --env ${BP_ENV}"), 0644)).To(Succeed())
+		})
+
+		it("stores substituted commands", func() {
+			Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{
+				Pass: true,
+				Plans: []libcnb.BuildPlan{
+					{
+						Provides: []libcnb.BuildPlanProvide{
+							{Name: "procfile"},
+						},
+						Requires: []libcnb.BuildPlanRequire{
+							{Name: "procfile", Metadata: map[string]interface{}{"application": procfile.Procfile{"web": "./server --env production"}}},
+						},
+					},
+				},
+			}))
+		})
+
+		it("returns error for unresolved placeholders", func() {
+			Expect(os.Unsetenv("BP_ENV")).To(Succeed())
+
+			_, err := detect.Detect(ctx)
+			Expect(err).To(MatchError("unable to substitute process type web from file\nunresolved placeholders BP_ENV"))
+		})
+	})
+
+	it("configures the health-checker buildpack in an alternate plan", func() {
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile"), []byte("web: web-command"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "Procfile.toml"), []byte("[web.health-check]\npath = \"/health\""), 0644)).To(Succeed())
+
+		p := procfile.Procfile{"web": map[string]interface{}{
+			"command":      "web-command",
+			"health-check": map[string]interface{}{"path": "/health"},
+		}}
+
+		Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{
+			Pass: true,
+			Plans: []libcnb.BuildPlan{
+				{
+					Provides: []libcnb.BuildPlanProvide{
+						{Name: "procfile"},
+					},
+					Requires: []libcnb.BuildPlanRequire{
+						{Name: "health-checker", Metadata: map[string]interface{}{
+							"launch":        true,
+							"health-checks": map[string]procfile.HealthCheck{"web": {Path: "/health"}},
 						}},
+						{Name: "procfile", Metadata: map[string]interface{}{"application": p}},
+					},
+				},
+				{
+					Provides: []libcnb.BuildPlanProvide{
+						{Name: "procfile"},
+					},
+					Requires: []libcnb.BuildPlanRequire{
+						{Name: "procfile", Metadata: map[string]interface{}{"application": p}},
 					},
 				},
 			},
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Filter selects the process types of a merged Procfile.
+type Filter struct {
+
+	// Include are the process types to keep. All process types are kept if empty.
+	Include []string
+
+	// Exclude are the process types to remove.
+	Exclude []string
+}
+
+// NewFilterFromEnvironment creates a Filter from BP_PROCFILE_INCLUDE_TYPES and BP_PROCFILE_EXCLUDE_TYPES.
+func NewFilterFromEnvironment() Filter {
+	return Filter{
+		Include: parseList(os.Getenv("BP_PROCFILE_INCLUDE_TYPES")),
+		Exclude: parseList(os.Getenv("BP_PROCFILE_EXCLUDE_TYPES")),
+	}
+}
+
+// Apply returns the process types of p selected by the filter, and the sorted names of those that were removed.
+func (f Filter) Apply(p Procfile) (Procfile, []string) {
+	var removed []string
+	selected := Procfile{}
+
+	for k, v := range p {
+		if (len(f.Include) > 0 && !slices.Contains(f.Include, k)) || slices.Contains(f.Exclude, k) {
+			removed = append(removed, k)
+			continue
+		}
+		selected[k] = v
+	}
+
+	sort.Strings(removed)
+	return selected, removed
+}
+
+// parseList splits a comma or whitespace delimited list.
+func parseList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
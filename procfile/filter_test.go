@@ -0,0 +1,54 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testFilter(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		p = procfile.Procfile{"clock": "clock-command", "web": "web-command", "worker": "worker-command"}
+	)
+
+	it("keeps all process types by default", func() {
+		Expect(procfile.NewFilterFromEnvironment().Apply(p)).To(Equal(p))
+	})
+
+	it("keeps only included process types", func() {
+		t.Setenv("BP_PROCFILE_INCLUDE_TYPES", "web, worker")
+
+		selected, removed := procfile.NewFilterFromEnvironment().Apply(p)
+		Expect(selected).To(Equal(procfile.Procfile{"web": "web-command", "worker": "worker-command"}))
+		Expect(removed).To(Equal([]string{"clock"}))
+	})
+
+	it("removes excluded process types", func() {
+		t.Setenv("BP_PROCFILE_EXCLUDE_TYPES", "clock worker")
+
+		selected, removed := procfile.NewFilterFromEnvironment().Apply(p)
+		Expect(selected).To(Equal(procfile.Procfile{"web": "web-command"}))
+		Expect(removed).To(Equal([]string{"clock", "worker"}))
+	})
+}
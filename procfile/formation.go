@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// FormationLabel is the name of the label describing the scaling and resource hints of process types.
+const FormationLabel = "formation"
+
+var (
+	// cpuQuantity matches a number of cores, or of millicores with the m suffix.
+	cpuQuantity = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?|[0-9]+m)$`)
+
+	// memoryQuantity matches a number of bytes, with an optional decimal or binary suffix.
+	memoryQuantity = regexp.MustCompile(`^[0-9]+(k|M|G|T|Ki|Mi|Gi|Ti)?$`)
+)
+
+// Formation describes how many instances of a process type to run and the resources each of them needs.
+type Formation struct {
+
+	// Replicas is the number of instances of the process type.
+	Replicas *int `toml:"replicas" json:"replicas,omitempty"`
+
+	// CPU is the number of cores of each instance, for example 0.5 or 500m.
+	CPU string `toml:"cpu" json:"cpu,omitempty"`
+
+	// Memory is the memory of each instance, for example 512Mi or 1G.
+	Memory string `toml:"memory" json:"memory,omitempty"`
+}
+
+// Validate returns an error if the formation contains invalid values.
+func (f Formation) Validate() error {
+	switch {
+	case f.Replicas != nil && *f.Replicas < 0:
+		return fmt.Errorf("formation replicas %d must not be negative", *f.Replicas)
+	case f.CPU != "" && !cpuQuantity.MatchString(f.CPU):
+		return fmt.Errorf("formation cpu %s must be a number of cores, for example 0.5 or 500m", f.CPU)
+	case f.Memory != "" && !memoryQuantity.MatchString(f.Memory):
+		return fmt.Errorf("formation memory %s must be a number of bytes with an optional suffix, for example 512Mi or 1G", f.Memory)
+	}
+
+	return nil
+}
+
+// NewFormations returns the formations of the process types of p, by process type.
+func NewFormations(p Procfile) (map[string]Formation, error) {
+	formations := map[string]Formation{}
+
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+		if d.Formation != nil {
+			formations[k] = *d.Formation
+		}
+	}
+
+	return formations, nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testFormation(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		replicas = func(i int) *int { return &i }
+	)
+
+	it("accepts valid formations", func() {
+		Expect(procfile.Formation{Replicas: replicas(0), CPU: "500m", Memory: "512Mi"}.Validate()).To(Succeed())
+		Expect(procfile.Formation{CPU: "1.5", Memory: "1G"}.Validate()).To(Succeed())
+		Expect(procfile.Formation{Memory: "1048576"}.Validate()).To(Succeed())
+	})
+
+	for message, formation := range map[string]procfile.Formation{
+		"formation replicas -1 must not be negative":                                                        {Replicas: replicas(-1)},
+		"formation cpu half must be a number of cores, for example 0.5 or 500m":                             {CPU: "half"},
+		"formation memory 512MB must be a number of bytes with an optional suffix, for example 512Mi or 1G": {Memory: "512MB"},
+	} {
+		it("rejects "+message, func() {
+			Expect(formation.Validate()).To(MatchError(message))
+		})
+	}
+
+	it("returns formations by process type", func() {
+		p := procfile.Procfile{
+			"web":    map[string]interface{}{"command": "web-command", "formation": map[string]interface{}{"replicas": int64(2), "memory": "512Mi"}},
+			"worker": "worker-command",
+		}
+
+		Expect(procfile.NewFormations(p)).To(Equal(map[string]procfile.Formation{
+			"web": {Replicas: replicas(2), Memory: "512Mi"},
+		}))
+	})
+
+	it("reads formations from bindings over the application's Procfile", func() {
+		path := t.TempDir()
+		bindPath := t.TempDir()
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: web-command"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "Procfile.toml"), []byte("[web.formation]\nreplicas = 1\ncpu = \"250m\""), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(bindPath, "Procfile.toml"), []byte("[web.formation]\nreplicas = 4"), 0644)).To(Succeed())
+		bindings := libcnb.Bindings{{
+			Name:   "production",
+			Type:   "Procfile",
+			Path:   bindPath,
+			Secret: map[string]string{"Procfile.toml": filepath.Join(bindPath, "Procfile.toml")},
+		}}
+
+		p, err := procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(procfile.NewFormations(p)).To(Equal(map[string]procfile.Formation{
+			"web": {Replicas: replicas(4)},
+		}))
+	})
+}
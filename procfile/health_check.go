@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	HealthChecksLabel     = "health-checks"  // HealthChecksLabel is the name of the label describing the health checks
+	HealthCheckerPlanName = "health-checker" // HealthCheckerPlanName is the plan entry provided by the health-checker buildpack
+)
+
+// HealthCheck describes how to check the health of a process type, either with an HTTP request or a command.
+type HealthCheck struct {
+
+	// Path is the path of the HTTP request.
+	Path string `toml:"path" json:"path,omitempty"`
+
+	// Port is the port of the HTTP request, the port of the process type if not set.
+	Port int `toml:"port" json:"port,omitempty"`
+
+	// Command is the command checking the health of the process type.
+	Command string `toml:"command" json:"command,omitempty"`
+
+	// Interval is the duration between two checks.
+	Interval string `toml:"interval" json:"interval,omitempty"`
+
+	// Timeout is the duration after which a check fails.
+	Timeout string `toml:"timeout" json:"timeout,omitempty"`
+}
+
+// Validate returns an error if the health check is not an HTTP or command check, or contains invalid values.
+func (h HealthCheck) Validate() error {
+	switch {
+	case h.Path == "" && h.Command == "":
+		return fmt.Errorf("health check requires a path or a command")
+	case h.Path != "" && h.Command != "":
+		return fmt.Errorf("health check requires either a path or a command, not both")
+	case h.Path != "" && !strings.HasPrefix(h.Path, "/"):
+		return fmt.Errorf("health check path %s must start with /", h.Path)
+	case h.Port < 0 || h.Port > 65535:
+		return fmt.Errorf("health check port %d must be between 1 and 65535", h.Port)
+	case h.Command != "" && h.Port != 0:
+		return fmt.Errorf("health check port requires a path")
+	}
+
+	for name, s := range map[string]string{"interval": h.Interval, "timeout": h.Timeout} {
+		if s == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("unable to parse health check %s %s\n%w", name, s, err)
+		} else if d <= 0 {
+			return fmt.Errorf("health check %s %s must be positive", name, s)
+		}
+	}
+
+	return nil
+}
+
+// NewHealthChecks returns the health checks of the process types of p, by process type.
+func NewHealthChecks(p Procfile) (map[string]HealthCheck, error) {
+	checks := map[string]HealthCheck{}
+
+	var types []string
+	for k := range p {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+
+	for _, k := range types {
+		d, err := NewDefinition(p[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+		if d.HealthCheck != nil {
+			checks[k] = *d.HealthCheck
+		}
+	}
+
+	return checks, nil
+}
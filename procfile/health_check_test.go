@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testHealthCheck(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("accepts HTTP and command checks", func() {
+		Expect(procfile.HealthCheck{Path: "/health", Port: 8080, Interval: "10s", Timeout: "1s"}.Validate()).To(Succeed())
+		Expect(procfile.HealthCheck{Command: "./bin/check"}.Validate()).To(Succeed())
+	})
+
+	for message, check := range map[string]procfile.HealthCheck{
+		"health check requires a path or a command":                    {Interval: "10s"},
+		"health check requires either a path or a command, not both":   {Path: "/health", Command: "./bin/check"},
+		"health check path health must start with /":                   {Path: "health"},
+		"health check port 70000 must be between 1 and 65535":          {Path: "/health", Port: 70000},
+		"health check port requires a path":                            {Command: "./bin/check", Port: 8080},
+		"health check timeout -1s must be positive":                    {Path: "/health", Timeout: "-1s"},
+		"unable to parse health check interval 10\ntime: missing unit": {Path: "/health", Interval: "10"},
+	} {
+		it("rejects "+message, func() {
+			err := check.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix(message))
+		})
+	}
+
+	it("returns health checks by process type", func() {
+		p := procfile.Procfile{
+			"web":    map[string]interface{}{"command": "web-command", "health-check": map[string]interface{}{"path": "/health", "port": int64(8080)}},
+			"worker": "worker-command",
+		}
+
+		Expect(procfile.NewHealthChecks(p)).To(Equal(map[string]procfile.HealthCheck{
+			"web": {Path: "/health", Port: 8080},
+		}))
+	})
+
+	it("returns error for invalid health checks", func() {
+		p := procfile.Procfile{"web": map[string]interface{}{"command": "web-command", "health-check": map[string]interface{}{}}}
+
+		_, err := procfile.NewHealthChecks(p)
+		Expect(err).To(MatchError("invalid process type web\nhealth check requires a path or a command"))
+	})
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+const (
+	OnFailureContinue = "continue" // OnFailureContinue starts the process type even if a hook fails
+	OnFailureFail     = "fail"     // OnFailureFail prevents the process type from starting if a hook fails
+
+	PreStartHelperPrefix = "pre-start-" // PreStartHelperPrefix prefixes the names of the exec.d helpers running hooks
+	HooksFile            = "hooks.toml" // HooksFile is the name of the hooks configuration in the hooks layer
+)
+
+// Hook is a process type run before other process types start.
+type Hook struct {
+
+	// Type is the process type of the hook.
+	Type string `toml:"type"`
+
+	// Command is the command of the hook.
+	Command string `toml:"command"`
+
+	// Arguments are the arguments of the command.
+	Arguments []string `toml:"args,omitempty"`
+
+	// Direct indicates whether the command is executed directly or within a shell.
+	Direct bool `toml:"direct"`
+
+	// OnFailure is the behavior when the hook fails.
+	OnFailure string `toml:"on-failure"`
+
+	// Types are the process types the hook runs before.
+	Types []string `toml:"types"`
+}
+
+// HooksConfiguration is the configuration of the hooks run by the exec.d helper.
+type HooksConfiguration struct {
+	Hooks []Hook `toml:"hooks"`
+}
+
+// NewHooksConfigurationFromPath reads a HooksConfiguration from path.
+func NewHooksConfigurationFromPath(path string) (HooksConfiguration, error) {
+	var c HooksConfiguration
+	if _, err := toml.DecodeFile(path, &c); err != nil {
+		return HooksConfiguration{}, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+	return c, nil
+}
+
+// NewHooks returns the hooks declared by the pre-start attribute of the entries of p, and by BP_PROCFILE_PRE_START,
+// with the processes created for them. A hook without process types runs before every other process type.
+func NewHooks(p Procfile, processes []libcnb.Process) ([]Hook, error) {
+	declared := map[string]PreStart{}
+	for _, t := range parseList(os.Getenv("BP_PROCFILE_PRE_START")) {
+		declared[t] = PreStart{}
+	}
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+		if d.PreStart != nil {
+			declared[k] = *d.PreStart
+		}
+	}
+
+	var hooks []Hook
+	for t, s := range declared {
+		i := slices.IndexFunc(processes, func(p libcnb.Process) bool { return p.Type == t })
+		if i == -1 {
+			return nil, fmt.Errorf("unable to declare pre-start hook %s, process type does not exist", t)
+		}
+
+		hook := Hook{
+			Type:      t,
+			Command:   processes[i].Command,
+			Arguments: processes[i].Arguments,
+			Direct:    processes[i].Direct,
+			OnFailure: s.OnFailure,
+			Types:     slices.Clone(s.Types),
+		}
+
+		switch hook.OnFailure {
+		case "":
+			hook.OnFailure = OnFailureFail
+		case OnFailureFail, OnFailureContinue:
+		default:
+			return nil, fmt.Errorf("invalid on-failure %q of pre-start hook %s, must be one of %s, %s", s.OnFailure, t, OnFailureFail, OnFailureContinue)
+		}
+
+		if len(hook.Types) == 0 {
+			for _, p := range processes {
+				if _, ok := declared[p.Type]; !ok {
+					hook.Types = append(hook.Types, p.Type)
+				}
+			}
+		}
+		for _, h := range hook.Types {
+			if h == t {
+				return nil, fmt.Errorf("unable to run pre-start hook %s before itself", t)
+			}
+			if !slices.ContainsFunc(processes, func(p libcnb.Process) bool { return p.Type == h }) {
+				return nil, fmt.Errorf("unable to run pre-start hook %s before process type %s, it does not exist", t, h)
+			}
+		}
+		sort.Strings(hook.Types)
+
+		hooks = append(hooks, hook)
+	}
+
+	sort.Slice(hooks, func(i int, j int) bool {
+		return hooks[i].Type < hooks[j].Type
+	})
+
+	return hooks, nil
+}
+
+// Hooks contributes a launch layer containing the helper running pre-start hooks before the process types they apply to.
+type Hooks struct {
+	LayerContributor libpak.LayerContributor
+	Logger           bard.Logger
+	Path             string
+	Hooks            []Hook
+}
+
+// NewHooksLayer creates a Hooks layer for hooks, using the helper binary of buildpack.
+func NewHooksLayer(buildpack libcnb.Buildpack, hooks []Hook) Hooks {
+	expected := map[string]interface{}{"buildpackInfo": buildpack.Info, "hooks": hooks}
+
+	return Hooks{
+		LayerContributor: libpak.NewLayerContributor("Pre-Start Hooks", expected, libcnb.LayerTypes{
+			Launch: true,
+		}),
+		Path:  filepath.Join(buildpack.Path, "bin", "helper"),
+		Hooks: hooks,
+	}
+}
+
+func (h Hooks) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	h.LayerContributor.Logger = h.Logger
+
+	return h.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+		in, err := os.Open(h.Path)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to open %s\n%w", h.Path, err)
+		}
+		defer in.Close()
+
+		out := filepath.Join(layer.Path, "bin", "helper")
+		if err := sherpa.CopyFile(in, out); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to copy %s to %s\n%w", h.Path, out, err)
+		}
+
+		file := filepath.Join(layer.Path, HooksFile)
+		f, err := os.Create(file)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to create %s\n%w", file, err)
+		}
+		defer f.Close()
+
+		if err := toml.NewEncoder(f).Encode(HooksConfiguration{Hooks: h.Hooks}); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to write %s\n%w", file, err)
+		}
+
+		for _, hook := range h.Hooks {
+			for _, t := range hook.Types {
+				link := layer.Exec.ProcessFilePath(t, PreStartHelperPrefix+hook.Type)
+				h.Logger.Bodyf("Running %s before process type %s", hook.Type, t)
+
+				if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+					return libcnb.Layer{}, fmt.Errorf("unable to create %s\n%w", filepath.Dir(link), err)
+				}
+				if err := os.Symlink(out, link); err != nil {
+					return libcnb.Layer{}, fmt.Errorf("unable to link %s to %s\n%w", out, link, err)
+				}
+			}
+		}
+
+		return layer, nil
+	})
+}
+
+// Name sorts after the profile layer, as the launcher runs the exec.d helpers of layers in alphabetical order and hooks
+// must see the environment exported by the profile scripts.
+func (Hooks) Name() string {
+	return "start-hooks"
+}
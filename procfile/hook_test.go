@@ -0,0 +1,122 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testHook(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		processes = []libcnb.Process{
+			{Type: "release", Command: "exec rake db:migrate"},
+			{Type: "web", Command: "web-command", Direct: true},
+			{Type: "worker", Command: "exec worker-command"},
+		}
+	)
+
+	it("returns no hooks without pre-start declarations", func() {
+		Expect(procfile.NewHooks(procfile.Procfile{"web": "web-command"}, processes)).To(BeEmpty())
+	})
+
+	it("returns hooks declared in the Procfile", func() {
+		p := procfile.Procfile{
+			"release": map[string]interface{}{
+				"command":   "rake db:migrate",
+				"pre-start": map[string]interface{}{"types": []interface{}{"web"}, "on-failure": "continue"},
+			},
+		}
+
+		Expect(procfile.NewHooks(p, processes)).To(Equal([]procfile.Hook{
+			{Type: "release", Command: "exec rake db:migrate", OnFailure: "continue", Types: []string{"web"}},
+		}))
+	})
+
+	it("returns hooks declared by BP_PROCFILE_PRE_START for all other process types", func() {
+		t.Setenv("BP_PROCFILE_PRE_START", "release")
+
+		Expect(procfile.NewHooks(procfile.Procfile{}, processes)).To(Equal([]procfile.Hook{
+			{Type: "release", Command: "exec rake db:migrate", OnFailure: "fail", Types: []string{"web", "worker"}},
+		}))
+	})
+
+	it("returns error for unknown hooks", func() {
+		t.Setenv("BP_PROCFILE_PRE_START", "migrate")
+
+		_, err := procfile.NewHooks(procfile.Procfile{}, processes)
+		Expect(err).To(MatchError("unable to declare pre-start hook migrate, process type does not exist"))
+	})
+
+	it("returns error for unknown process types", func() {
+		p := procfile.Procfile{"release": map[string]interface{}{"pre-start": map[string]interface{}{"types": []interface{}{"clock"}}}}
+
+		_, err := procfile.NewHooks(p, processes)
+		Expect(err).To(MatchError("unable to run pre-start hook release before process type clock, it does not exist"))
+	})
+
+	it("returns error for hooks running before themselves", func() {
+		p := procfile.Procfile{"release": map[string]interface{}{"pre-start": map[string]interface{}{"types": []interface{}{"release"}}}}
+
+		_, err := procfile.NewHooks(p, processes)
+		Expect(err).To(MatchError("unable to run pre-start hook release before itself"))
+	})
+
+	it("returns error for invalid failure behavior", func() {
+		p := procfile.Procfile{"release": map[string]interface{}{"pre-start": map[string]interface{}{"on-failure": "retry"}}}
+
+		_, err := procfile.NewHooks(p, processes)
+		Expect(err).To(MatchError(`invalid on-failure "retry" of pre-start hook release, must be one of fail, continue`))
+	})
+
+	it("contributes the helper and hooks", func() {
+		buildpack := libcnb.Buildpack{Path: t.TempDir()}
+		Expect(os.MkdirAll(filepath.Join(buildpack.Path, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(buildpack.Path, "bin", "helper"), []byte("test-helper"), 0755)).To(Succeed())
+
+		hooks := []procfile.Hook{{Type: "release", Command: "exec rake db:migrate", OnFailure: "fail", Types: []string{"web", "worker"}}}
+		layers := libcnb.Layers{Path: t.TempDir()}
+		h := procfile.NewHooksLayer(buildpack, hooks)
+		layer, err := layers.Layer(h.Name())
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = h.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LayerTypes.Launch).To(BeTrue())
+		Expect(procfile.NewHooksConfigurationFromPath(filepath.Join(layer.Path, "hooks.toml"))).To(Equal(procfile.HooksConfiguration{Hooks: hooks}))
+		for _, t := range []string{"web", "worker"} {
+			Expect(os.Readlink(layer.Exec.ProcessFilePath(t, "pre-start-release"))).To(Equal(filepath.Join(layer.Path, "bin", "helper")))
+		}
+	})
+
+	it("runs hooks after the profile helper", func() {
+		names := []string{procfile.NewHooksLayer(libcnb.Buildpack{}, nil).Name(), procfile.NewProfile(libcnb.Buildpack{}, nil).Name()}
+
+		Expect(sort.StringsAreSorted(names)).To(BeFalse())
+	})
+}
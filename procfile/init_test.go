@@ -27,6 +27,23 @@ func TestUnit(t *testing.T) {
 	suite := spec.New("procfile", spec.Report(report.Terminal{}))
 	suite("Build", testBuild)
 	suite("Detect", testDetect)
+	suite("Formation", testFormation)
+	suite("Filter", testFilter)
+	suite("HealthCheck", testHealthCheck)
+	suite("Hook", testHook)
+	suite("Pipeline", testPipeline)
+	suite("Plan", testPlan)
+	suite("Policy", testPolicy)
+	suite("Port", testPort)
+	suite("Process", testProcess)
+	suite("Profile", testProfile)
 	suite("Procfile", testProcfile)
+	suite("References", testReferences)
+	suite("Report", testReport)
+	suite("Schedule", testSchedule)
+	suite("Secrets", testSecrets)
+	suite("Shell", testShell)
+	suite("Supervisor", testSupervisor)
+	suite("Variables", testVariables)
 	suite.Run(t)
 }
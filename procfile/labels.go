@@ -0,0 +1,37 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buildpacks/libcnb"
+)
+
+// LabelPrefix prefixes the image labels contributed by the buildpack.
+const LabelPrefix = "io.paketo.procfile."
+
+// NewLabel creates the image label LabelPrefix + name with the JSON encoding of v.
+func NewLabel(name string, v interface{}) (libcnb.Label, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return libcnb.Label{}, fmt.Errorf("unable to encode label %s\n%w", name, err)
+	}
+
+	return libcnb.Label{Key: LabelPrefix + name, Value: string(b)}, nil
+}
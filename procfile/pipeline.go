@@ -0,0 +1,129 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// Pipeline creates the processes of Procfile entries the way the buildpack contributes them, so that the command line
+// interface starts the same processes as the image.
+type Pipeline struct {
+	Logger bard.Logger
+
+	// Execution describes how processes are executed by default and what the stack supports.
+	Execution Execution
+
+	// Shell is the shell configured to execute the processes that would be executed within a shell, if any.
+	Shell string
+
+	// Exec indicates whether single commands executed within a shell are prefixed with exec.
+	Exec bool
+}
+
+// NewPipeline creates a Pipeline for a stack providing a shell or not and a launcher interpolating direct processes or
+// not, configured by BP_DIRECT_PROCESS, BP_PROCFILE_SHELL and BP_PROCFILE_EXEC.
+func NewPipeline(shell bool, interpolate bool, logger bard.Logger) Pipeline {
+	configured := strings.TrimSpace(os.Getenv("BP_PROCFILE_SHELL"))
+
+	return Pipeline{
+		Logger: logger,
+		Execution: Execution{
+			Direct:      !shell || sherpa.ResolveBool("BP_DIRECT_PROCESS"),
+			Shell:       shell || configured != "",
+			Interpolate: interpolate,
+		},
+		Shell: configured,
+		Exec:  resolveBool("BP_PROCFILE_EXEC", true),
+	}
+}
+
+// Processes creates the processes of the entries of p. Environment variable references of direct processes are
+// translated if the execution interpolates them, and passed unchanged with a warning otherwise. Commands executed within
+// a shell are prefixed with exec if enabled, and executed with the configured shell if any.
+func (pl Pipeline) Processes(p Procfile) ([]libcnb.Process, error) {
+	processes, err := NewProcesses(p, pl.Execution)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pl.Execution.Interpolate {
+		for _, proc := range processes {
+			if !proc.Direct {
+				continue
+			}
+			d, err := NewDefinition(p[proc.Type])
+			if err != nil {
+				return nil, err
+			}
+			if names := EnvironmentReferences(d.Command); len(names) > 0 {
+				pl.Logger.Headerf("Warning: process type %s references environment variables %s, which the launcher does not interpolate in direct processes before Buildpack API %s",
+					proc.Type, strings.Join(names, ", "), InterpolationAPI)
+			}
+		}
+	}
+
+	if pl.Exec {
+		var rewritten []string
+		for i, p := range processes {
+			if p.Direct {
+				continue
+			}
+			if command, ok := NewExecCommand(p.Command); ok {
+				processes[i].Command = command
+				rewritten = append(rewritten, p.Type)
+			}
+		}
+
+		if len(rewritten) > 0 {
+			sort.Strings(rewritten)
+			pl.Logger.Headerf("Prefixing commands of process types %s with exec, so that they receive signals", strings.Join(rewritten, ", "))
+		}
+	}
+
+	if pl.Shell != "" {
+		var wrapped []string
+		for i, p := range processes {
+			if p.Direct {
+				continue
+			}
+			if found := BashSyntax(p.Command); len(found) > 0 && IsPOSIXShell(pl.Shell) {
+				pl.Logger.Headerf("Warning: process type %s uses bash-only syntax (%s), which %s might not support",
+					p.Type, strings.Join(found, ", "), pl.Shell)
+			}
+			processes[i] = NewShellProcess(p, pl.Shell)
+			wrapped = append(wrapped, p.Type)
+		}
+
+		if len(wrapped) > 0 {
+			sort.Strings(wrapped)
+			pl.Logger.Headerf("Executing process types %s with %s, as configured by BP_PROCFILE_SHELL", strings.Join(wrapped, ", "), pl.Shell)
+		}
+	}
+
+	sort.Slice(processes, func(i int, j int) bool {
+		return processes[i].Type < processes[j].Type
+	})
+
+	return processes, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testPipeline(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		b *bytes.Buffer
+	)
+
+	it.Before(func() {
+		b = &bytes.Buffer{}
+	})
+
+	it("creates processes sorted by type with exec prefixed", func() {
+		Expect(procfile.NewPipeline(true, false, bard.NewLogger(b)).Processes(procfile.Procfile{
+			"worker": "worker-command",
+			"web":    "web-command && other-command",
+		})).To(Equal([]libcnb.Process{
+			{Type: "web", Command: "web-command && other-command"},
+			{Type: "worker", Command: "exec worker-command"},
+		}))
+		Expect(b.String()).To(ContainSubstring("Prefixing commands of process types worker with exec"))
+	})
+
+	it("executes processes with the configured shell", func() {
+		t.Setenv("BP_PROCFILE_SHELL", "/bin/bash")
+		t.Setenv("BP_PROCFILE_EXEC", "false")
+
+		Expect(procfile.NewPipeline(false, false, bard.NewLogger(b)).Processes(procfile.Procfile{
+			"web": map[string]interface{}{"command": "web-command", "direct": false},
+		})).To(Equal([]libcnb.Process{
+			{Type: "web", Command: "/bin/bash", Arguments: []string{"-c", "web-command"}, Direct: true},
+		}))
+	})
+
+	it("translates environment variable references of direct processes", func() {
+		t.Setenv("BP_DIRECT_PROCESS", "true")
+
+		Expect(procfile.NewPipeline(true, true, bard.NewLogger(b)).Processes(procfile.Procfile{"web": "./server --port $PORT"})).
+			To(Equal([]libcnb.Process{{Type: "web", Command: "./server", Arguments: []string{"--port", "$(PORT)"}, Direct: true}}))
+	})
+
+	it("warns about environment variable references of direct processes without interpolation", func() {
+		t.Setenv("BP_DIRECT_PROCESS", "true")
+
+		Expect(procfile.NewPipeline(true, false, bard.NewLogger(b)).Processes(procfile.Procfile{"web": "./server --port $PORT $PORT"})).
+			To(Equal([]libcnb.Process{{Type: "web", Command: "./server", Arguments: []string{"--port", "$PORT", "$PORT"}, Direct: true}}))
+		Expect(b.String()).To(ContainSubstring("Warning: process type web references environment variables PORT, which the launcher does not interpolate"))
+	})
+}
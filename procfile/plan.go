@@ -0,0 +1,95 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+
+	"github.com/buildpacks/libcnb"
+)
+
+const (
+	PlanEntryName = "procfile" // PlanEntryName is the name of the buildpack plan entry provided and required by this buildpack
+
+	PlanApplication = "application" // PlanApplication is the key of the plan entry metadata holding the application's Procfile
+
+	SourceBuildpack = "buildpack" // SourceBuildpack identifies entries contributed by other buildpacks through the buildpack plan
+)
+
+// NewProcfileFromPlan reads the procfile entries of plan. It returns the Procfile required by this buildpack during
+// detection with metadata of the form {application = {<type> = "<command>"}}, if any, and a Source for each entry
+// required by another buildpack, in plan order. Other buildpacks contribute process types with metadata of the form
+// {buildpack = "<id>", processes = {<type> = "<command>"}}. Entries of other forms, for example without metadata, are
+// ignored.
+func NewProcfileFromPlan(plan libcnb.BuildpackPlan) (Procfile, []Source, error) {
+	var (
+		app           Procfile
+		contributions []Source
+	)
+
+	for _, e := range plan.Entries {
+		if e.Name != PlanEntryName {
+			continue
+		}
+
+		if v, ok := e.Metadata[PlanApplication]; ok {
+			var p map[string]interface{}
+			switch v := v.(type) {
+			case Procfile:
+				p = v
+			case map[string]interface{}:
+				p = v
+			default:
+				return nil, nil, fmt.Errorf("invalid %s metadata %v, must be a table of process types", PlanApplication, v)
+			}
+
+			// Copy, so that changes to the Procfile do not change the plan
+			if app == nil {
+				app = Procfile{}
+			}
+			for k, v := range p {
+				app[k] = v
+			}
+			continue
+		}
+
+		processes, ok := e.Metadata["processes"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name := SourceBuildpack
+		if id, ok := e.Metadata["buildpack"].(string); ok && id != "" {
+			name = fmt.Sprintf("%s:%s", SourceBuildpack, id)
+		}
+
+		p := Procfile{}
+		for k, v := range processes {
+			if !processType.MatchString(k) {
+				return nil, nil, fmt.Errorf("invalid process type %q contributed by %s", k, name)
+			}
+			if _, ok := v.(string); !ok {
+				return nil, nil, fmt.Errorf("invalid command for process type %s contributed by %s, must be a string", k, name)
+			}
+			p[k] = v
+		}
+
+		contributions = append(contributions, Source{Name: name, Procfile: p})
+	}
+
+	return app, contributions, nil
+}
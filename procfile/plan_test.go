@@ -0,0 +1,98 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testPlan(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("returns nothing without procfile entries", func() {
+		app, contributions, err := procfile.NewProcfileFromPlan(libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{{Name: "jvm-application"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(app).To(BeNil())
+		Expect(contributions).To(BeEmpty())
+	})
+
+	it("separates the Procfile from contributions of other buildpacks", func() {
+		app, contributions, err := procfile.NewProcfileFromPlan(libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{Name: "procfile", Metadata: map[string]interface{}{"processes": map[string]interface{}{"web": "contributed-command"}}},
+				{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "app-command"}}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(app).To(Equal(procfile.Procfile{"web": "app-command"}))
+		Expect(contributions).To(Equal([]procfile.Source{
+			{Name: "buildpack", Procfile: procfile.Procfile{"web": "contributed-command"}},
+		}))
+	})
+
+	it("ignores entries without metadata and merges application entries", func() {
+		app, contributions, err := procfile.NewProcfileFromPlan(libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{"web": "app-command", "processes": "processes-command"}}},
+				{Name: "procfile"},
+				{Name: "procfile", Metadata: map[string]interface{}{}},
+				{Name: "procfile", Metadata: map[string]interface{}{"application": map[string]interface{}{"worker": "worker-command"}}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(app).To(Equal(procfile.Procfile{"web": "app-command", "processes": "processes-command", "worker": "worker-command"}))
+		Expect(contributions).To(BeEmpty())
+	})
+
+	it("does not change the plan when the Procfile changes", func() {
+		metadata := map[string]interface{}{"web": "app-command"}
+
+		app, _, err := procfile.NewProcfileFromPlan(libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{{Name: "procfile", Metadata: map[string]interface{}{"application": metadata}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		app["worker"] = "worker-command"
+
+		Expect(metadata).To(Equal(map[string]interface{}{"web": "app-command"}))
+	})
+
+	it("fails with an invalid process type", func() {
+		_, _, err := procfile.NewProcfileFromPlan(libcnb.BuildpackPlan{
+			Entries: []libcnb.BuildpackPlanEntry{
+				{Name: "procfile", Metadata: map[string]interface{}{
+					"buildpack": "test/language",
+					"processes": map[string]interface{}{"web server": "contributed-command"},
+				}},
+			},
+		})
+
+		Expect(err).To(MatchError(`invalid process type "web server" contributed by buildpack:test/language`))
+	})
+}
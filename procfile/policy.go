@@ -0,0 +1,265 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	"github.com/mattn/go-shellwords"
+	"github.com/paketo-buildpacks/libpak/bindings"
+)
+
+const (
+	PolicyBindingType = "ProcfilePolicy" // PolicyBindingType is used to resolve bindings containing policies
+	PolicyFile        = "policy.toml"    // PolicyFile is the key of a policy binding containing the policy
+
+	RuleAllow = "allow" // RuleAllow is the kind of rules that every process they apply to must match
+	RuleDeny  = "deny"  // RuleDeny is the kind of rules that no process they apply to may match
+)
+
+// shells are the names of shells, whose -c option executes a process within a shell.
+var shells = []string{"ash", "bash", "busybox", "dash", "fish", "ksh", "sh", "zsh"}
+
+// Rule matches processes by the criteria it declares, all of which must match.
+type Rule struct {
+
+	// Name is the name of the rule, reported with violations.
+	Name string `toml:"name"`
+
+	// Types are the process types the rule applies to, all if empty.
+	Types []string `toml:"types"`
+
+	// Shell matches processes executed within a shell if true, or directly if false.
+	Shell *bool `toml:"shell"`
+
+	// Command is a regular expression matching the command line of processes, the command executed by the shell if
+	// they are executed within a shell, or the command and arguments otherwise.
+	Command string `toml:"command"`
+
+	// Executable is a regular expression matching the executable of processes, resolved against the application
+	// directory if relative, and against the bin directories of the layers if a bare name.
+	Executable string `toml:"executable"`
+
+	command    *regexp.Regexp
+	executable *regexp.Regexp
+}
+
+// Policy restricts the processes contributed to the image.
+type Policy struct {
+
+	// Allow are the rules that every process they apply to must match.
+	Allow []Rule `toml:"allow"`
+
+	// Deny are the rules that no process they apply to may match.
+	Deny []Rule `toml:"deny"`
+}
+
+// Violation is a process type violating a rule of a policy.
+type Violation struct {
+
+	// Type is the process type.
+	Type string
+
+	// Kind is the kind of the rule, RuleAllow or RuleDeny.
+	Kind string
+
+	// Rule is the name of the rule.
+	Rule string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s rule %s", v.Kind, v.Rule)
+}
+
+// NewPolicyFromBindings creates a Policy combining the rules of the bindings of type ProcfilePolicy.
+func NewPolicyFromBindings(binds libcnb.Bindings) (Policy, error) {
+	var policy Policy
+
+	for _, b := range bindings.Resolve(binds, bindings.OfType(PolicyBindingType)) {
+		content, ok := b.Secret[PolicyFile]
+		if !ok {
+			return Policy{}, fmt.Errorf("binding %s of type %s requires a %s key", b.Name, PolicyBindingType, PolicyFile)
+		}
+		s := fmt.Sprintf("%s of binding %s", PolicyFile, b.Name)
+
+		var p Policy
+		md, err := toml.Decode(content, &p)
+		if err != nil {
+			return Policy{}, fmt.Errorf("unable to decode %s\n%w", s, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			var keys []string
+			for _, k := range undecoded {
+				keys = append(keys, k.String())
+			}
+			sort.Strings(keys)
+			return Policy{}, fmt.Errorf("unknown keys %s in %s", strings.Join(keys, ", "), s)
+		}
+
+		for _, rules := range [][]Rule{p.Allow, p.Deny} {
+			for i := range rules {
+				if err := rules[i].compile(); err != nil {
+					return Policy{}, fmt.Errorf("invalid rule in %s\n%w", s, err)
+				}
+			}
+		}
+
+		policy.Allow = append(policy.Allow, p.Allow...)
+		policy.Deny = append(policy.Deny, p.Deny...)
+	}
+
+	return policy, nil
+}
+
+// Len returns the number of rules of the policy.
+func (p Policy) Len() int {
+	return len(p.Allow) + len(p.Deny)
+}
+
+// Evaluate returns the violations of the policy by processes, sorted by process type, for an application at path and
+// the layers of all buildpacks in layers.
+func (p Policy) Evaluate(processes []libcnb.Process, path string, layers string) []Violation {
+	var violations []Violation
+
+	for _, proc := range processes {
+		for _, r := range p.Allow {
+			if r.applies(proc) && !r.matches(proc, path, layers) {
+				violations = append(violations, Violation{Type: proc.Type, Kind: RuleAllow, Rule: r.Name})
+			}
+		}
+		for _, r := range p.Deny {
+			if r.applies(proc) && r.matches(proc, path, layers) {
+				violations = append(violations, Violation{Type: proc.Type, Kind: RuleDeny, Rule: r.Name})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i int, j int) bool {
+		return violations[i].Type < violations[j].Type
+	})
+
+	return violations
+}
+
+// compile validates the rule and compiles its regular expressions.
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule requires a name")
+	}
+	if r.Shell == nil && r.Command == "" && r.Executable == "" {
+		return fmt.Errorf("rule %s requires shell, command or executable", r.Name)
+	}
+
+	var err error
+	if r.Command != "" {
+		if r.command, err = regexp.Compile(r.Command); err != nil {
+			return fmt.Errorf("unable to compile command of rule %s\n%w", r.Name, err)
+		}
+	}
+	if r.Executable != "" {
+		if r.executable, err = regexp.Compile(r.Executable); err != nil {
+			return fmt.Errorf("unable to compile executable of rule %s\n%w", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applies indicates whether the rule applies to the process type of process.
+func (r Rule) applies(process libcnb.Process) bool {
+	return len(r.Types) == 0 || slices.Contains(r.Types, process.Type)
+}
+
+// matches indicates whether process matches all criteria of the rule.
+func (r Rule) matches(process libcnb.Process, path string, layers string) bool {
+	command, shell := shellCommand(process)
+	if !shell {
+		command = strings.Join(append([]string{process.Command}, process.Arguments...), " ")
+	}
+
+	if r.Shell != nil && *r.Shell != shell {
+		return false
+	}
+	if r.command != nil && !r.command.MatchString(command) {
+		return false
+	}
+	if r.executable != nil && !r.executable.MatchString(executable(process, path, layers)) {
+		return false
+	}
+
+	return true
+}
+
+// shellCommand returns the command executed within a shell by process and true, or false if it is executed directly.
+// Processes executed directly with the -c option of a shell are executed within a shell.
+func shellCommand(process libcnb.Process) (string, bool) {
+	if !process.Direct {
+		return process.Command, true
+	}
+	if len(process.Arguments) > 1 && process.Arguments[0] == "-c" && slices.Contains(shells, filepath.Base(process.Command)) {
+		return process.Arguments[1], true
+	}
+	return "", false
+}
+
+// executable returns the executable of process, the first word of its command other than exec and variable
+// assignments if it is executed within a shell, resolved against path if relative. Bare names are resolved against the
+// bin directories of the layers in layers, the first in lexical order providing them, and returned unchanged otherwise
+// as they are found on the PATH of the run image. Compound commands, commands with comments and commands starting with
+// another shell builtin have no single executable, and return an empty string.
+func executable(process libcnb.Process, path string, layers string) string {
+	e := process.Command
+
+	if command, ok := shellCommand(process); ok {
+		if strings.ContainsAny(command, "\n\r#") {
+			return ""
+		}
+
+		p := shellwords.NewParser()
+		words, err := p.Parse(command)
+		if err != nil || p.Position != -1 {
+			return ""
+		}
+
+		e = ""
+		for _, w := range words {
+			if w == "exec" || (strings.Contains(w, "=") && !strings.Contains(strings.SplitN(w, "=", 2)[0], "/")) {
+				continue
+			} else if slices.Contains(shellBuiltins, w) {
+				return ""
+			}
+			e = w
+			break
+		}
+	}
+
+	if strings.Contains(e, "/") && !filepath.IsAbs(e) {
+		e = filepath.Join(path, e)
+	} else if e != "" && !strings.Contains(e, "/") && layers != "" {
+		if found, err := filepath.Glob(filepath.Join(layers, "*", "*", "bin", e)); err == nil && len(found) > 0 {
+			e = found[0]
+		}
+	}
+	return e
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testPolicy(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	newPolicy := func(policy ...string) (procfile.Policy, error) {
+		var binds libcnb.Bindings
+		for _, p := range policy {
+			binds = append(binds, libcnb.Binding{Name: "policy", Type: "ProcfilePolicy", Secret: map[string]string{"policy.toml": p}})
+		}
+		return procfile.NewPolicyFromBindings(binds)
+	}
+
+	it("returns an empty policy without bindings", func() {
+		Expect(newPolicy()).To(HaveField("Len()", 0))
+	})
+
+	it("combines the rules of several bindings", func() {
+		p, err := newPolicy(`
+[[deny]]
+name = "no-shell"
+shell = true
+`, `
+[[allow]]
+name = "trusted-executables"
+executable = '^/(workspace|layers)/'
+
+[[deny]]
+name = "curl-pipe-sh"
+command = 'curl[^|]*\|\s*(ba)?sh'
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Len()).To(Equal(3))
+	})
+
+	context("evaluate", func() {
+		var policy procfile.Policy
+
+		it.Before(func() {
+			var err error
+			policy, err = newPolicy(`
+[[allow]]
+name = "trusted-executables"
+executable = '^/(workspace|layers)/'
+
+[[deny]]
+name = "no-shell"
+shell = true
+types = ["web"]
+
+[[deny]]
+name = "curl-pipe-sh"
+command = 'curl[^|]*\|\s*(ba)?sh'
+`)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("returns no violations for compliant processes", func() {
+			Expect(policy.Evaluate([]libcnb.Process{
+				{Type: "web", Command: "./server", Arguments: []string{"--port", "8080"}, Direct: true},
+				{Type: "worker", Command: "exec bin/worker --queue default"},
+				{Type: "task", Command: "FOO=bar exec /layers/paketo-buildpacks_node/bin/task"},
+			}, "/workspace", "")).To(BeEmpty())
+		})
+
+		it("returns violations by process type and rule", func() {
+			Expect(policy.Evaluate([]libcnb.Process{
+				{Type: "worker", Command: "curl -s https://example.com/install | sh"},
+				{Type: "web", Command: "exec ./server"},
+				{Type: "clock", Command: "/usr/bin/clock", Direct: true},
+			}, "/workspace", "")).To(Equal([]procfile.Violation{
+				{Type: "clock", Kind: "allow", Rule: "trusted-executables"},
+				{Type: "web", Kind: "deny", Rule: "no-shell"},
+				{Type: "worker", Kind: "allow", Rule: "trusted-executables"},
+				{Type: "worker", Kind: "deny", Rule: "curl-pipe-sh"},
+			}))
+		})
+
+		it("evaluates processes executed with the -c option of a shell as shell processes", func() {
+			Expect(policy.Evaluate([]libcnb.Process{
+				{Type: "web", Command: "/bin/bash", Arguments: []string{"-c", "exec ./server"}, Direct: true},
+			}, "/workspace", "")).To(Equal([]procfile.Violation{
+				{Type: "web", Kind: "deny", Rule: "no-shell"},
+			}))
+		})
+
+		it("does not resolve the executables of compound commands and commands starting with shell builtins", func() {
+			policy, err := newPolicy(`
+[[allow]]
+name = "trusted-executables"
+executable = '^/(workspace|layers)/'
+
+[[deny]]
+name = "no-cd"
+executable = '^cd$'
+`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(policy.Evaluate([]libcnb.Process{
+				{Type: "clock", Command: "[ -f config.yml ] && /workspace/bin/clock"},
+				{Type: "web", Command: "cd /workspace && ./server"},
+				{Type: "worker", Command: "cd /workspace"},
+			}, "/workspace", "")).To(Equal([]procfile.Violation{
+				{Type: "clock", Kind: "allow", Rule: "trusted-executables"},
+				{Type: "web", Kind: "allow", Rule: "trusted-executables"},
+				{Type: "worker", Kind: "allow", Rule: "trusted-executables"},
+			}))
+		})
+
+		it("resolves bare executables against the bin directories of layers", func() {
+			layers := t.TempDir()
+			Expect(os.MkdirAll(filepath.Join(layers, "paketo-buildpacks_bellsoft-liberica", "jre", "bin"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layers, "paketo-buildpacks_bellsoft-liberica", "jre", "bin", "java"), []byte{}, 0755)).To(Succeed())
+
+			policy, err := newPolicy(fmt.Sprintf(`
+[[allow]]
+name = "layer-executables"
+executable = '^%s/'
+`, regexp.QuoteMeta(layers)))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(policy.Evaluate([]libcnb.Process{
+				{Type: "web", Command: "exec java -jar app.jar"},
+				{Type: "worker", Command: "python", Arguments: []string{"worker.py"}, Direct: true},
+			}, "/workspace", layers)).To(Equal([]procfile.Violation{
+				{Type: "worker", Kind: "allow", Rule: "layer-executables"},
+			}))
+		})
+	})
+
+	it("returns error without policy key", func() {
+		_, err := procfile.NewPolicyFromBindings(libcnb.Bindings{{Name: "policy", Type: "ProcfilePolicy", Secret: map[string]string{"rules": ""}}})
+		Expect(err).To(MatchError("binding policy of type ProcfilePolicy requires a policy.toml key"))
+	})
+
+	it("returns error for unknown keys", func() {
+		_, err := newPolicy(`
+[[deny]]
+name = "no-shell"
+shel = true
+`)
+		Expect(err).To(MatchError("unknown keys deny.shel in policy.toml of binding policy"))
+	})
+
+	it("returns error for rules without name", func() {
+		_, err := newPolicy(`
+[[deny]]
+shell = true
+`)
+		Expect(err).To(MatchError("invalid rule in policy.toml of binding policy\nrule requires a name"))
+	})
+
+	it("returns error for rules without criteria", func() {
+		_, err := newPolicy(`
+[[deny]]
+name = "everything"
+types = ["web"]
+`)
+		Expect(err).To(MatchError("invalid rule in policy.toml of binding policy\nrule everything requires shell, command or executable"))
+	})
+
+	it("returns error for invalid regular expressions", func() {
+		_, err := newPolicy(`
+[[deny]]
+name = "broken"
+command = "curl ("
+`)
+		Expect(err).To(MatchError(HavePrefix("invalid rule in policy.toml of binding policy\nunable to compile command of rule broken\n")))
+	})
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// PortsLabel is the name of the label describing the ports of process types.
+const PortsLabel = "ports"
+
+// portPatterns match the port of a command, in the first submatch.
+var portPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:^|\s)--port(?:=|\s+)["']?(\d+)`),
+	regexp.MustCompile(`(?:^|\s)(?:export\s+)?PORT=["']?(\d+)`),
+	regexp.MustCompile(`\$\{PORT:-(\d+)\}`),
+}
+
+// NewPorts returns the ports of the process types of p, by process type. A port is either declared by the port attribute
+// of a process type, or inferred from --port and PORT= patterns of its command. The types whose port was inferred are
+// returned, sorted.
+func NewPorts(p Procfile) (map[string]int, []string, error) {
+	ports := map[string]int{}
+	var inferred []string
+
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+
+		if d.Port != 0 {
+			ports[k] = d.Port
+			continue
+		}
+
+		for _, pattern := range portPatterns {
+			if m := pattern.FindStringSubmatch(d.Command); m != nil {
+				port, err := strconv.Atoi(m[1])
+				if err != nil || !validPort(port) {
+					return nil, nil, fmt.Errorf("invalid port %s inferred from the command of process type %s, must be between 1 and 65535", m[1], k)
+				}
+				ports[k] = port
+				inferred = append(inferred, k)
+				break
+			}
+		}
+	}
+
+	sort.Strings(inferred)
+	return ports, inferred, nil
+}
+
+// validPort indicates whether port is a valid TCP port.
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testPort(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("returns declared ports", func() {
+		ports, inferred, err := procfile.NewPorts(procfile.Procfile{
+			"web":    map[string]interface{}{"command": "./server --port 9090", "port": int64(8080)},
+			"worker": "worker-command",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ports).To(Equal(map[string]int{"web": 8080}))
+		Expect(inferred).To(BeEmpty())
+	})
+
+	for command, port := range map[string]int{
+		"./server --port 8080":               8080,
+		"./server --port=8081 --debug":       8081,
+		"PORT=8082 ./server":                 8082,
+		"export PORT=8083 && ./server":       8083,
+		"./server --listen :${PORT:-8084}":   8084,
+		`./server --port "8085"`:             8085,
+		"./server --admin-port 9000 -x 8000": 0,
+	} {
+		it("infers the port of "+command, func() {
+			ports, inferred, err := procfile.NewPorts(procfile.Procfile{"web": command})
+			Expect(err).NotTo(HaveOccurred())
+
+			if port == 0 {
+				Expect(ports).To(BeEmpty())
+				Expect(inferred).To(BeEmpty())
+			} else {
+				Expect(ports).To(Equal(map[string]int{"web": port}))
+				Expect(inferred).To(Equal([]string{"web"}))
+			}
+		})
+	}
+
+	it("returns error for invalid inferred ports", func() {
+		_, _, err := procfile.NewPorts(procfile.Procfile{"web": "./server --port 99999"})
+		Expect(err).To(MatchError("invalid port 99999 inferred from the command of process type web, must be between 1 and 65535"))
+	})
+
+	it("returns error for invalid declared ports", func() {
+		_, _, err := procfile.NewPorts(procfile.Procfile{"web": map[string]interface{}{"command": "./server", "port": int64(0x10000)}})
+		Expect(err).To(MatchError("invalid process type web\nport 65536 must be between 1 and 65535"))
+	})
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/mattn/go-shellwords"
+)
+
+// Execution describes how processes are executed by default and what the stack supports.
+type Execution struct {
+
+	// Direct indicates whether processes are executed directly, unless their definition overrides it.
+	Direct bool
+
+	// Shell indicates whether a shell is available to execute processes.
+	Shell bool
+
+	// Interpolate indicates whether environment variable references of direct processes are translated for the
+	// launcher to interpolate them.
+	Interpolate bool
+}
+
+// NewProcesses creates a process for each entry of p. Processes are executed directly, with their command split into a
+// command and arguments, or within a shell as described by execution, unless their definition overrides it. Requesting
+// execution within a shell when none is available is an error. Environment variable references of direct processes are
+// translated with NewLauncherCommand if execution interpolates them.
+func NewProcesses(p Procfile, execution Execution) ([]libcnb.Process, error) {
+	var processes []libcnb.Process
+
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+		if d.Command == "" {
+			return nil, fmt.Errorf("process type %s has no command", k)
+		}
+
+		direct := execution.Direct
+		if d.Direct != nil {
+			direct = *d.Direct
+		}
+
+		process := libcnb.Process{Type: k}
+
+		if direct {
+			c := d.Command
+			if execution.Interpolate {
+				if c, _, err = NewLauncherCommand(c); err != nil {
+					return nil, fmt.Errorf("unable to interpolate process type %s\n%w", k, err)
+				}
+			}
+
+			s, err := shellwords.Parse(c)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse %s\n%w", d.Command, err)
+			}
+			if len(s) == 0 {
+				return nil, fmt.Errorf("process type %s has no command", k)
+			}
+
+			process.Command = s[0]
+			process.Arguments = s[1:]
+			process.Direct = true
+		} else if !execution.Shell {
+			return nil, fmt.Errorf("unable to execute process type %s within a shell, the stack does not provide one", k)
+		} else {
+			process.Command = d.Command
+			process.Direct = false
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// shellBuiltins are the shell keywords and builtins that cannot be exec'd, or whose executables might not exist.
+var shellBuiltins = []string{"!", ".", ":", "[[", "alias", "bg", "bind", "break", "builtin", "caller", "case", "cd",
+	"command", "compgen", "complete", "compopt", "continue", "coproc", "declare", "dirs", "disown", "enable", "eval",
+	"exec", "exit", "export", "fc", "fg", "for", "function", "getopts", "hash", "help", "history", "if", "jobs", "let",
+	"local", "logout", "mapfile", "popd", "pushd", "read", "readarray", "readonly", "return", "select", "set", "shift",
+	"shopt", "source", "suspend", "time", "times", "trap", "type", "typeset", "ulimit", "umask", "unalias", "unset",
+	"until", "wait", "while", "{"}
+
+// NewExecCommand returns command prefixed with exec, so that the shell is replaced by the command instead of remaining
+// its parent and not forwarding signals, and true if command is a single simple command. Otherwise, command is returned
+// unchanged and false.
+func NewExecCommand(command string) (string, bool) {
+	// Comments are not parsed, and might hide the end of a compound command
+	if strings.ContainsAny(command, "\n\r#") {
+		return command, false
+	}
+
+	p := shellwords.NewParser()
+	s, err := p.Parse(command)
+	if err != nil || p.Position != -1 || len(s) == 0 {
+		return command, false
+	}
+
+	if slices.Contains(shellBuiltins, s[0]) || strings.Contains(s[0], "=") {
+		return command, false
+	}
+
+	return fmt.Sprintf("exec %s", strings.TrimSpace(command)), true
+}
+
+// posixShells are the names of shells that do not support bash-only syntax.
+var posixShells = []string{"ash", "busybox", "dash", "sh"}
+
+// bashSyntax matches bash-only syntax, by the name it is reported with.
+var bashSyntax = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"arrays", regexp.MustCompile(`(^|[\s;&|(])[A-Za-z_][A-Za-z0-9_]*\+?=\(|\$\{[A-Za-z_][A-Za-z0-9_]*\[`)},
+	{"[[", regexp.MustCompile(`(^|[\s;&|(])\[\[\s`)},
+	{"source", regexp.MustCompile(`(^|[\s;&|(])source\s`)},
+}
+
+// NewShellProcess returns process executed directly with shell, passing its command to the shell's -c option, if
+// it is executed within a shell. Otherwise, process is returned unchanged.
+func NewShellProcess(process libcnb.Process, shell string) libcnb.Process {
+	if process.Direct {
+		return process
+	}
+
+	process.Arguments = append([]string{"-c", process.Command}, process.Arguments...)
+	process.Command = shell
+	process.Direct = true
+	return process
+}
+
+// IsPOSIXShell indicates whether shell is known not to support bash-only syntax.
+func IsPOSIXShell(shell string) bool {
+	return slices.Contains(posixShells, filepath.Base(shell))
+}
+
+// BashSyntax returns the bash-only syntax used by command.
+func BashSyntax(command string) []string {
+	var found []string
+	for _, s := range bashSyntax {
+		if s.pattern.MatchString(command) {
+			found = append(found, s.name)
+		}
+	}
+	return found
+}
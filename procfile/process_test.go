@@ -0,0 +1,150 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testProcess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("creates shell processes", func() {
+		Expect(procfile.NewProcesses(procfile.Procfile{"web": "test-command argument"}, procfile.Execution{Shell: true})).To(Equal([]libcnb.Process{
+			{Type: "web", Command: "test-command argument"},
+		}))
+	})
+
+	it("creates direct processes", func() {
+		Expect(procfile.NewProcesses(procfile.Procfile{"web": "test-command 'quoted argument'"}, procfile.Execution{Direct: true})).To(Equal([]libcnb.Process{
+			{Type: "web", Command: "test-command", Arguments: []string{"quoted argument"}, Direct: true},
+		}))
+	})
+
+	it("honors per-process execution overrides", func() {
+		p := procfile.Procfile{
+			"web":    map[string]interface{}{"command": "test-command argument", "direct": true},
+			"worker": map[string]interface{}{"command": "test-command argument", "direct": false},
+		}
+
+		processes, err := procfile.NewProcesses(p, procfile.Execution{Shell: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(processes).To(ConsistOf(
+			libcnb.Process{Type: "web", Command: "test-command", Arguments: []string{"argument"}, Direct: true},
+			libcnb.Process{Type: "worker", Command: "test-command argument"},
+		))
+	})
+
+	it("returns error if shell execution is requested without a shell", func() {
+		p := procfile.Procfile{"web": map[string]interface{}{"command": "test-command", "direct": false}}
+
+		_, err := procfile.NewProcesses(p, procfile.Execution{Direct: true})
+		Expect(err).To(MatchError("unable to execute process type web within a shell, the stack does not provide one"))
+	})
+
+	it("returns error if a process type has no command", func() {
+		_, err := procfile.NewProcesses(procfile.Procfile{"web": map[string]interface{}{"direct": true}}, procfile.Execution{Shell: true})
+		Expect(err).To(MatchError("process type web has no command"))
+	})
+
+	context("NewShellProcess", func() {
+		it("wraps processes executed within a shell", func() {
+			Expect(procfile.NewShellProcess(libcnb.Process{Type: "web", Command: "test-command | tee log"}, "/bin/bash")).To(Equal(
+				libcnb.Process{Type: "web", Command: "/bin/bash", Arguments: []string{"-c", "test-command | tee log"}, Direct: true},
+			))
+		})
+
+		it("leaves direct processes unchanged", func() {
+			process := libcnb.Process{Type: "web", Command: "test-command", Arguments: []string{"arg"}, Direct: true}
+			Expect(procfile.NewShellProcess(process, "/bin/bash")).To(Equal(process))
+		})
+	})
+
+	it("identifies POSIX shells", func() {
+		Expect(procfile.IsPOSIXShell("/bin/sh")).To(BeTrue())
+		Expect(procfile.IsPOSIXShell("dash")).To(BeTrue())
+		Expect(procfile.IsPOSIXShell("/bin/bash")).To(BeFalse())
+	})
+
+	context("BashSyntax", func() {
+		for command, expected := range map[string][]string{
+			"opts=(-a -b) && test-command":       {"arrays"},
+			"test-command ${opts[@]}":            {"arrays"},
+			"[[ -f .env ]] && source .env; test": {"[[", "source"},
+		} {
+			it("finds bash-only syntax in "+command, func() {
+				Expect(procfile.BashSyntax(command)).To(Equal(expected))
+			})
+		}
+
+		it("finds nothing in POSIX commands", func() {
+			Expect(procfile.BashSyntax("[ -f .env ] && . .env; test-command --source=x")).To(BeEmpty())
+		})
+	})
+
+	context("NewExecCommand", func() {
+		for command, expected := range map[string]string{
+			"test-command":                 "exec test-command",
+			" test-command 'argument; b' ": "exec test-command 'argument; b'",
+			"java -jar $HOME/app.jar":      "exec java -jar $HOME/app.jar",
+		} {
+			it("prefixes simple command "+command+" with exec", func() {
+				c, ok := procfile.NewExecCommand(command)
+				Expect(ok).To(BeTrue())
+				Expect(c).To(Equal(expected))
+			})
+		}
+
+		for _, command := range []string{
+			"exec test-command",
+			"cd app && test-command",
+			"test-command; other-command",
+			"test-command | tee log",
+			"test-command > log",
+			"test-command &",
+			"PORT=8080 test-command",
+			"source .env",
+			"test-command\nother-command",
+			"(test-command)",
+			"time make",
+			"let x=1",
+			"command -v test-command",
+			"builtin echo test",
+			"shopt -s globstar",
+			"typeset -x A=b",
+			"coproc test-command",
+			"pushd app",
+			"popd",
+			"test-command # comment",
+			"test-command #; other-command",
+		} {
+			it("leaves compound command "+command+" unchanged", func() {
+				c, ok := procfile.NewExecCommand(command)
+				Expect(ok).To(BeFalse())
+				Expect(c).To(Equal(command))
+			})
+		}
+	})
+}
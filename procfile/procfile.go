@@ -22,8 +22,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/bindings"
 
 	"github.com/buildpacks/libcnb"
@@ -32,12 +35,53 @@ import (
 // Procfile is a map between a logical name and a command.
 type Procfile map[string]interface{}
 
+// processType matches the valid names of process types.
+var processType = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 const (
-	BindingType = "Procfile" // BindingType is used to resolve a binding containing a Procfile
+	BindingType     = "Procfile" // BindingType is used to resolve a binding containing a Procfile
+	BindingPriority = "priority" // BindingPriority is the optional key ordering multiple bindings, higher values take precedence
+
+	SourceBinding     = "binding"     // SourceBinding identifies entries read from a binding
+	SourceEnvironment = "environment" // SourceEnvironment identifies entries read from BP_PROCFILE_DEFAULT_PROCESS
+	SourceFile        = "file"        // SourceFile identifies entries read from the application's Procfile
+
+	Tombstone = "~" // Tombstone is the command of an entry that removes its process type from lower precedence sources
 )
 
+// DefaultPrecedence lists the Procfile sources from highest to lowest priority when BP_PROCFILE_PRECEDENCE is not set.
+var DefaultPrecedence = []string{SourceBinding, SourceFile, SourceEnvironment}
+
+// NewPrecedence parses a comma-delimited list of Procfile sources in priority order, highest first. Sources that are
+// not listed keep their default relative order below the listed ones. An empty list returns DefaultPrecedence.
+func NewPrecedence(s string) ([]string, error) {
+	var precedence []string
+	for _, source := range strings.Split(s, ",") {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if source == "" {
+			continue
+		}
+		if !slices.Contains(DefaultPrecedence, source) {
+			return nil, fmt.Errorf("unknown Procfile source %q, must be one of %s", source, strings.Join(DefaultPrecedence, ", "))
+		}
+		if slices.Contains(precedence, source) {
+			return nil, fmt.Errorf("duplicate Procfile source %q", source)
+		}
+		precedence = append(precedence, source)
+	}
+
+	for _, source := range DefaultPrecedence {
+		if !slices.Contains(precedence, source) {
+			precedence = append(precedence, source)
+		}
+	}
+
+	return precedence, nil
+}
+
 // NewProcfileFromEnvironment creates a Procfile by reading environment variable BP_PROCFILE_DEFAULT_PROCESS if it exists.
-// If it does not exist, returns an empty Procfile.
+// If it does not exist, returns an empty Procfile. A value of Tombstone is returned as is, removing the web process type
+// when merged.
 func NewProcfileFromEnvironment() (Procfile, error) {
 	if process, isSet := os.LookupEnv("BP_PROCFILE_DEFAULT_PROCESS"); isSet {
 		if process != "" {
@@ -47,12 +91,147 @@ func NewProcfileFromEnvironment() (Procfile, error) {
 	return nil, nil
 }
 
-// NewProcfileFromPath creates a Procfile by reading Procfile from path if it exists.  If it does not exist, returns an
-// empty Procfile.
+// NewProcfileFromPath creates a Procfile by reading Procfile and Procfile.toml from path if they exist.  If neither
+// exists, returns an empty Procfile.
 func NewProcfileFromPath(path string) (Procfile, error) {
-	pat := regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+	p, err := newProcfileFromFile(filepath.Join(path, "Procfile"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newProcfileFromStructuredFile(filepath.Join(path, StructuredFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return combine(p, s), nil
+}
+
+// combine adds the structured definitions s to the commands of p.
+func combine(p Procfile, s Procfile) Procfile {
+	for k, v := range s {
+		p[k] = mergeEntries(p[k], v)
+	}
+	return p
+}
+
+// NewProcfileFromBinding creates a Procfile by reading Procfile from bindings if it exists.  If it does not exist, returns an
+// empty Procfile. Multiple bindings are merged in the order described by NewSourcesFromBindings. Entries with an empty
+// or Tombstone command are returned as Tombstone.
+func NewProcfileFromBinding(binds libcnb.Bindings) (Procfile, error) {
+	sources, err := NewSourcesFromBindings(binds)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Procfile{}
+	for _, s := range sources {
+		for k, v := range s.Procfile {
+			p[k] = v
+		}
+	}
+
+	return p, nil
+}
+
+// NewSourcesFromBindings creates a Source for each binding containing a Procfile, from lowest to highest precedence.
+// Bindings are ordered by their optional integer priority key, higher values taking precedence, and then by name.
+func NewSourcesFromBindings(binds libcnb.Bindings) ([]Source, error) {
+	type binding struct {
+		libcnb.Binding
+		priority int
+	}
+
+	var candidates []binding
+	for _, b := range bindings.Resolve(binds, bindings.OfType(BindingType)) {
+		priority := 0
+		if s, ok := b.Secret[BindingPriority]; ok {
+			var err error
+			if priority, err = strconv.Atoi(strings.TrimSpace(s)); err != nil {
+				return nil, fmt.Errorf("unable to parse %s of binding %s\n%w", BindingPriority, b.Name, err)
+			}
+		}
+		candidates = append(candidates, binding{Binding: b, priority: priority})
+	}
+
+	sort.SliceStable(candidates, func(i int, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	var sources []Source
+	for _, b := range candidates {
+		p, err := newProcfileFromBinding(b.Binding)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, Source{Name: fmt.Sprintf("%s:%s", SourceBinding, b.Name), Procfile: p})
+	}
+
+	return sources, nil
+}
+
+// newProcfileFromBinding creates a Procfile from the Procfile and Procfile.toml keys of binding if either exists.
+// Otherwise, every key other than BindingPriority is a process type with its value as command. Empty or Tombstone
+// commands are returned as Tombstone.
+func newProcfileFromBinding(binding libcnb.Binding) (Procfile, error) {
+	procfile, hasProcfile := binding.SecretFilePath(BindingType)
+	structured, hasStructured := binding.SecretFilePath(StructuredFile)
+
+	if hasProcfile || hasStructured {
+		p := Procfile{}
+		if hasProcfile {
+			var err error
+			if p, err = newProcfileFromFile(procfile, true); err != nil {
+				return nil, err
+			}
+		}
+
+		s := Procfile{}
+		if hasStructured {
+			var err error
+			if s, err = newProcfileFromStructuredFile(structured); err != nil {
+				return nil, err
+			}
+		}
+
+		return combine(p, s), nil
+	}
+
+	var keys []string
+	valid := true
+	p := Procfile{}
+	for k, v := range binding.Secret {
+		if k == BindingPriority {
+			continue
+		}
+
+		keys = append(keys, k)
+		if !processType.MatchString(k) {
+			valid = false
+		} else if command := strings.TrimSpace(v); command == "" || command == Tombstone {
+			p[k] = Tombstone
+		} else {
+			p[k] = v
+		}
+	}
+
+	if !valid || len(p) == 0 {
+		sort.Strings(keys)
+		return nil, fmt.Errorf("unable to find Procfile from binding %s, expected a %s or %s key or keys named after process types, found [%s]",
+			binding.Name, BindingType, StructuredFile, strings.Join(keys, ", "))
+	}
+
+	return p, nil
+}
+
+// newProcfileFromFile parses the Procfile f if it exists. Entries with an empty command are ignored, or returned as
+// Tombstone, along with entries whose command is Tombstone, if tombstones is true.
+func newProcfileFromFile(f string, tombstones bool) (Procfile, error) {
+	pat := regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
 
-	f := filepath.Join(path, "Procfile")
 	file, err := os.OpenFile(f, os.O_RDONLY, 0644)
 	if err != nil && os.IsNotExist(err) {
 		return Procfile{}, nil
@@ -66,7 +245,13 @@ func NewProcfileFromPath(path string) (Procfile, error) {
 	s := bufio.NewScanner(file)
 	for s.Scan() {
 		parts := pat.FindStringSubmatch(s.Text())
-		if len(parts) > 0 {
+		if len(parts) == 0 {
+			continue
+		}
+
+		if command := strings.TrimSpace(parts[2]); tombstones && (command == "" || command == Tombstone) {
+			p[parts[1]] = Tombstone
+		} else if command != "" {
 			p[parts[1]] = parts[2]
 		}
 	}
@@ -78,30 +263,26 @@ func NewProcfileFromPath(path string) (Procfile, error) {
 	return p, nil
 }
 
-// NewProcfileFromBinding creates a Procfile by reading Procfile from bindings if it exists.  If it does not exist, returns an
-// empty Procfile.
-func NewProcfileFromBinding(binds libcnb.Bindings) (Procfile, error) {
-
-	p := Procfile{}
-	if binding, ok, err := bindings.ResolveOne(binds, bindings.OfType(BindingType)); err != nil {
-		return nil, fmt.Errorf("unable to resolve binding\n%w", err)
-	} else if ok {
-		if path, ok := binding.SecretFilePath(BindingType); ok {
-			if p, err = NewProcfileFromPath(filepath.Dir(path)); err != nil {
-				return nil, err
-			}
-			return p, nil
-		} else {
-			return nil, fmt.Errorf("unable to find Procfile from binding")
-		}
-	} else {
-		return p, nil
+// NewProcfileFromEnvironmentOrPathOrBinding attempts to create a merged Procfile from environment and/or given path and bindings.
+// Duplicate process types are resolved in the order configured by BP_PROCFILE_PRECEDENCE. If none can be created,
+// returns an empty Procfile.
+func NewProcfileFromEnvironmentOrPathOrBinding(path string, binds libcnb.Bindings) (Procfile, error) {
+	sources, err := NewSources(path, binds)
+	if err != nil {
+		return nil, err
 	}
+
+	p, _ := Merge(sources...)
+	return p, nil
 }
 
-// NewProcfileFromEnvironmentOrPathOrBinding attempts to create a merged Procfile from environment and/or given path and bindings.
-// If none can be created, returns an empty Procfile.
-func NewProcfileFromEnvironmentOrPathOrBinding(path string, binds libcnb.Bindings) (Procfile, error) {
+// NewSources reads the Procfiles from environment, given path and bindings and returns them from lowest to highest
+// precedence, as configured by BP_PROCFILE_PRECEDENCE.
+func NewSources(path string, binds libcnb.Bindings) ([]Source, error) {
+	precedence, err := NewPrecedence(os.Getenv("BP_PROCFILE_PRECEDENCE"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse BP_PROCFILE_PRECEDENCE\n%w", err)
+	}
 	procEnv, err := NewProcfileFromEnvironment()
 	if err != nil {
 		return nil, err
@@ -110,26 +291,22 @@ func NewProcfileFromEnvironmentOrPathOrBinding(path string, binds libcnb.Binding
 	if err != nil {
 		return nil, err
 	}
-	procBind, err := NewProcfileFromBinding(binds)
+	procBinds, err := NewSourcesFromBindings(binds)
 	if err != nil {
 		return nil, err
 	}
-	if len(procEnv) > 0 && len(procPath)+len(procBind) > 0 {
-		l := bard.NewLogger(os.Stdout)
-		l.Logger.Info("A Procfile exists and BP_PROCFILE_DEFAULT_PROCESS is set, entries in Procfile take precedence")
-	}
 
-	procBind = mergeProcfiles(procEnv, procPath, procBind)
-	return procBind, nil
-}
-
-// merge procfiles from binding + path, overwriting duplicate keys - binding takes precedence
-func mergeProcfiles(maps ...map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-	for _, m := range maps {
-		for k, v := range m {
-			result[k] = v
+	var sources []Source
+	for i := len(precedence) - 1; i >= 0; i-- {
+		switch precedence[i] {
+		case SourceBinding:
+			sources = append(sources, procBinds...)
+		case SourceEnvironment:
+			sources = append(sources, Source{Name: SourceEnvironment, Procfile: procEnv})
+		case SourceFile:
+			sources = append(sources, Source{Name: SourceFile, Procfile: procPath})
 		}
 	}
-	return result
+
+	return sources, nil
 }
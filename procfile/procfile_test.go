@@ -17,6 +17,7 @@
 package procfile_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -141,4 +142,277 @@ func testProcfile(t *testing.T, context spec.G, it spec.S) {
 
 	})
 
+	context("multiple bindings", func() {
+		var orgPath, teamPath string
+
+		it.Before(func() {
+			orgPath, teamPath = t.TempDir(), t.TempDir()
+			Expect(os.WriteFile(filepath.Join(orgPath, "Procfile"), []byte("web: org-command\nclock: clock-command"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(teamPath, "Procfile"), []byte("web: team-command\nworker: worker-command"), 0644)).To(Succeed())
+			bindings = libcnb.Bindings{
+				libcnb.Binding{
+					Name:   "team",
+					Type:   "Procfile",
+					Path:   teamPath,
+					Secret: map[string]string{"Procfile": filepath.Join(teamPath, "Procfile")},
+				},
+				libcnb.Binding{
+					Name:   "org",
+					Type:   "Procfile",
+					Path:   orgPath,
+					Secret: map[string]string{"Procfile": filepath.Join(orgPath, "Procfile")},
+				},
+			}
+		})
+
+		it("merges bindings ordered by name", func() {
+			Expect(procfile.NewProcfileFromBinding(bindings)).To(Equal(procfile.Procfile{
+				"clock":  "clock-command",
+				"web":    "team-command",
+				"worker": "worker-command",
+			}))
+		})
+
+		it("merges bindings ordered by priority", func() {
+			bindings[1].Secret["priority"] = "10"
+
+			Expect(procfile.NewProcfileFromBinding(bindings)).To(Equal(procfile.Procfile{
+				"clock":  "clock-command",
+				"web":    "org-command",
+				"worker": "worker-command",
+			}))
+		})
+
+		it("returns a source per binding", func() {
+			sources, err := procfile.NewSourcesFromBindings(bindings)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sources).To(Equal([]procfile.Source{
+				{Name: "binding:org", Procfile: procfile.Procfile{"web": "org-command", "clock": "clock-command"}},
+				{Name: "binding:team", Procfile: procfile.Procfile{"web": "team-command", "worker": "worker-command"}},
+			}))
+		})
+
+		it("reports conflicts between bindings", func() {
+			sources, err := procfile.NewSources(path, bindings)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, report := procfile.Merge(sources...)
+			Expect(report[1]).To(Equal(procfile.ReportEntry{
+				Entry:    procfile.Entry{Source: "binding:team", Command: "team-command"},
+				Type:     "web",
+				Shadowed: []procfile.Entry{{Source: "binding:org", Command: "org-command"}},
+			}))
+		})
+
+		it("fails with an invalid priority", func() {
+			bindings[0].Secret["priority"] = "high"
+
+			_, err := procfile.NewProcfileFromBinding(bindings)
+			Expect(err).To(MatchError(ContainSubstring("unable to parse priority of binding team")))
+		})
+
+		it("fails with a binding without Procfile", func() {
+			delete(bindings[0].Secret, "Procfile")
+
+			_, err := procfile.NewProcfileFromBinding(bindings)
+			Expect(err).To(MatchError("unable to find Procfile from binding team, expected a Procfile or Procfile.toml key or keys named after process types, found []"))
+		})
+	})
+
+	context("binding with a key per process type", func() {
+		it.Before(func() {
+			bindings = libcnb.Bindings{libcnb.Binding{
+				Name: "name1",
+				Type: "Procfile",
+				Path: bindPath,
+				Secret: map[string]string{
+					"web":      "bind-test-command",
+					"worker":   "~",
+					"clock":    "",
+					"priority": "1",
+				},
+			}}
+		})
+
+		it("returns a Procfile with a process type per key", func() {
+			Expect(procfile.NewProcfileFromBinding(bindings)).To(Equal(procfile.Procfile{
+				"web":    "bind-test-command",
+				"worker": "~",
+				"clock":  "~",
+			}))
+		})
+
+		it("fails listing the keys found when they are not process types", func() {
+			bindings[0].Secret["config.yml"] = "port: 8080"
+
+			_, err := procfile.NewProcfileFromBinding(bindings)
+			Expect(err).To(MatchError("unable to find Procfile from binding name1, expected a Procfile or Procfile.toml key or keys named after process types, found [clock, config.yml, web, worker]"))
+		})
+	})
+
+	it("returns tombstones for empty entries from given binding", func() {
+		Expect(os.WriteFile(filepath.Join(bindPath, "Procfile"), []byte("clock:\nworker: ~\nweb: bind-test-command"), 0644)).To(Succeed())
+		bindings = libcnb.Bindings{libcnb.Binding{
+			Name:   "name1",
+			Type:   "Procfile",
+			Path:   bindPath,
+			Secret: map[string]string{"Procfile": filepath.Join(bindPath, "Procfile")},
+		}}
+
+		Expect(procfile.NewProcfileFromBinding(bindings)).To(Equal(procfile.Procfile{"clock": "~", "worker": "~", "web": "bind-test-command"}))
+	})
+
+	it("ignores empty entries from file", func() {
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("clock:\nweb: test-command"), 0644)).To(Succeed())
+
+		Expect(procfile.NewProcfileFromPath(path)).To(Equal(procfile.Procfile{"web": "test-command"}))
+	})
+
+	it("removes process types tombstoned by binding", func() {
+		Expect(os.WriteFile(filepath.Join(bindPath, "Procfile"), []byte("clock:\nworker: ~"), 0644)).To(Succeed())
+		bindings = libcnb.Bindings{libcnb.Binding{
+			Name:   "name1",
+			Type:   "Procfile",
+			Path:   bindPath,
+			Secret: map[string]string{"Procfile": filepath.Join(bindPath, "Procfile")},
+		}}
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: path-test-command\nclock: clock-command\nworker: worker-command"), 0644)).To(Succeed())
+
+		Expect(procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)).To(Equal(procfile.Procfile{"web": "path-test-command"}))
+	})
+
+	it("removes the web process type tombstoned by BP_PROCFILE_DEFAULT_PROCESS", func() {
+		t.Setenv("BP_PROCFILE_DEFAULT_PROCESS", "~")
+		t.Setenv("BP_PROCFILE_PRECEDENCE", "environment")
+		Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: path-test-command\nworker: worker-command"), 0644)).To(Succeed())
+
+		Expect(procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)).To(Equal(procfile.Procfile{"worker": "worker-command"}))
+	})
+
+	context("Procfile.toml", func() {
+		it("adds structured definitions to the commands of Procfile", func() {
+			Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: test-command\nworker: worker-command"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(path, "Procfile.toml"), []byte(`
+[web]
+direct = true
+
+[clock]
+command = "clock-command"
+`), 0644)).To(Succeed())
+
+			Expect(procfile.NewProcfileFromPath(path)).To(Equal(procfile.Procfile{
+				"web":    map[string]interface{}{"command": "test-command", "direct": true},
+				"worker": "worker-command",
+				"clock":  map[string]interface{}{"command": "clock-command"},
+			}))
+		})
+
+		it("returns structured definitions from given binding", func() {
+			Expect(os.WriteFile(filepath.Join(bindPath, "Procfile.toml"), []byte("[web]\ncommand = \"bind-command\"\ndirect = false"), 0644)).To(Succeed())
+			bindings = libcnb.Bindings{libcnb.Binding{
+				Name:   "name1",
+				Type:   "Procfile",
+				Path:   bindPath,
+				Secret: map[string]string{"Procfile.toml": filepath.Join(bindPath, "Procfile.toml")},
+			}}
+
+			Expect(procfile.NewProcfileFromBinding(bindings)).To(Equal(procfile.Procfile{
+				"web": map[string]interface{}{"command": "bind-command", "direct": false},
+			}))
+		})
+
+		it("merges attributes with lower precedence sources", func() {
+			Expect(os.WriteFile(filepath.Join(bindPath, "Procfile"), []byte("web: bind-command"), 0644)).To(Succeed())
+			bindings = libcnb.Bindings{libcnb.Binding{
+				Name:   "name1",
+				Type:   "Procfile",
+				Path:   bindPath,
+				Secret: map[string]string{"Procfile": filepath.Join(bindPath, "Procfile")},
+			}}
+			Expect(os.WriteFile(filepath.Join(path, "Procfile.toml"), []byte("[web]\ncommand = \"path-command\"\ndirect = true"), 0644)).To(Succeed())
+
+			Expect(procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)).To(Equal(procfile.Procfile{
+				"web": map[string]interface{}{"command": "bind-command", "direct": true},
+			}))
+		})
+
+		it("fails with an unknown attribute", func() {
+			Expect(os.WriteFile(filepath.Join(path, "Procfile.toml"), []byte("[web]\ndirekt = true"), 0644)).To(Succeed())
+
+			_, err := procfile.NewProcfileFromPath(path)
+			Expect(err).To(MatchError(fmt.Sprintf("invalid process type web in %s\nunknown keys direkt", filepath.Join(path, "Procfile.toml"))))
+		})
+
+		it("fails with an entry that is not a table", func() {
+			Expect(os.WriteFile(filepath.Join(path, "Procfile.toml"), []byte("web = \"test-command\""), 0644)).To(Succeed())
+
+			_, err := procfile.NewProcfileFromPath(path)
+			Expect(err).To(MatchError(fmt.Sprintf("invalid process type web in %s, must be a table named after the process type", filepath.Join(path, "Procfile.toml"))))
+		})
+	})
+
+	context("NewPrecedence", func() {
+		it("returns the default precedence when empty", func() {
+			Expect(procfile.NewPrecedence("")).To(Equal([]string{"binding", "file", "environment"}))
+		})
+
+		it("appends unlisted sources in default order", func() {
+			Expect(procfile.NewPrecedence(" Environment ")).To(Equal([]string{"environment", "binding", "file"}))
+		})
+
+		it("fails with an unknown source", func() {
+			_, err := procfile.NewPrecedence("binding,image")
+			Expect(err).To(MatchError(ContainSubstring(`unknown Procfile source "image"`)))
+		})
+
+		it("fails with a duplicate source", func() {
+			_, err := procfile.NewPrecedence("file,binding,file")
+			Expect(err).To(MatchError(ContainSubstring(`duplicate Procfile source "file"`)))
+		})
+	})
+
+	context("given BP_PROCFILE_PRECEDENCE", func() {
+		it.Before(func() {
+			t.Setenv("BP_PROCFILE_DEFAULT_PROCESS", "env-test-command")
+			Expect(os.WriteFile(filepath.Join(bindPath, "Procfile"), []byte("web: bind-test-command"), 0644)).To(Succeed())
+			bindings = libcnb.Bindings{libcnb.Binding{
+				Name:   "name1",
+				Type:   "Procfile",
+				Path:   bindPath,
+				Secret: map[string]string{"Procfile": filepath.Join(bindPath, "Procfile")},
+			}}
+			Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("web: path-test-command"), 0644)).To(Succeed())
+		})
+
+		for precedence, command := range map[string]string{
+			"binding,file,environment": "bind-test-command",
+			"binding,environment,file": "bind-test-command",
+			"file,binding,environment": "path-test-command",
+			"file,environment,binding": "path-test-command",
+			"environment,binding,file": "env-test-command",
+			"environment,file,binding": "env-test-command",
+		} {
+			it(fmt.Sprintf("uses the command from the first source of %s", precedence), func() {
+				t.Setenv("BP_PROCFILE_PRECEDENCE", precedence)
+
+				Expect(procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)).To(Equal(procfile.Procfile{"web": command}))
+			})
+		}
+
+		it("uses the next source in order when the first does not define the process type", func() {
+			t.Setenv("BP_PROCFILE_PRECEDENCE", "environment,file,binding")
+			Expect(os.WriteFile(filepath.Join(bindPath, "Procfile"), []byte("worker: bind-test-command"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(path, "Procfile"), []byte("worker: path-test-command"), 0644)).To(Succeed())
+
+			Expect(procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)).To(Equal(procfile.Procfile{"web": "env-test-command", "worker": "path-test-command"}))
+		})
+
+		it("fails with an unknown source", func() {
+			t.Setenv("BP_PROCFILE_PRECEDENCE", "binding,buildpack")
+
+			_, err := procfile.NewProcfileFromEnvironmentOrPathOrBinding(path, bindings)
+			Expect(err).To(MatchError(ContainSubstring(`unknown Procfile source "buildpack"`)))
+		})
+	})
 }
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// ProfileHelper is the name of the exec.d helper evaluating the profile scripts of the application.
+const ProfileHelper = "profile"
+
+var (
+	// assignment matches a single assignment, optionally exported.
+	assignment = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+	// declaration matches the export of a variable without assignment.
+	declaration = regexp.MustCompile(`^export(\s+[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+	// bareValue matches unquoted values without shell syntax other than simple variable references.
+	bareValue = regexp.MustCompile(`^([A-Za-z0-9_./:,@%+=-]|\$[A-Za-z_][A-Za-z0-9_]*|\$\{[A-Za-z_][A-Za-z0-9_]*\})*$`)
+
+	// doubleQuotedValue matches double-quoted values without shell syntax other than simple variable references.
+	doubleQuotedValue = regexp.MustCompile(`^"([^"\\$` + "`" + `]|\$[A-Za-z_][A-Za-z0-9_]*|\$\{[A-Za-z_][A-Za-z0-9_]*\})*"$`)
+
+	// singleQuotedValue matches single-quoted values.
+	singleQuotedValue = regexp.MustCompile(`^'[^']*'$`)
+)
+
+// Assignment is a simple environment variable assignment of a profile script.
+type Assignment struct {
+
+	// Name is the name of the variable.
+	Name string
+
+	// Value is the value of the variable, unquoted.
+	Value string
+
+	// Expand indicates whether variable references of Value are expanded.
+	Expand bool
+}
+
+// ProfileError describes a line of a profile script that cannot be evaluated without a shell.
+type ProfileError struct {
+	File string
+	Line int
+	Text string
+}
+
+func (e ProfileError) Error() string {
+	return fmt.Sprintf("%s:%d requires a shell to evaluate %q, only export NAME=value lines are supported", e.File, e.Line, e.Text)
+}
+
+// NewProfileScripts returns the profile scripts of the application at path in the order they are evaluated by Heroku:
+// the .profile.d/*.sh scripts in alphabetical order, followed by .profile.
+func NewProfileScripts(path string) ([]string, error) {
+	scripts, err := filepath.Glob(filepath.Join(path, ".profile.d", "*.sh"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list .profile.d scripts\n%w", err)
+	}
+	sort.Strings(scripts)
+
+	if _, err := os.Stat(filepath.Join(path, ".profile")); err == nil {
+		scripts = append(scripts, filepath.Join(path, ".profile"))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to stat .profile\n%w", err)
+	}
+
+	return scripts, nil
+}
+
+// NewAssignments parses the profile script f. Lines other than blank lines, comments and simple assignments return a
+// ProfileError.
+func NewAssignments(f string) ([]Assignment, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s\n%w", f, err)
+	}
+	defer file.Close()
+
+	var assignments []Assignment
+
+	s := bufio.NewScanner(file)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || declaration.MatchString(line) {
+			continue
+		}
+
+		parts := assignment.FindStringSubmatch(line)
+		if parts == nil {
+			return nil, ProfileError{File: f, Line: n, Text: line}
+		}
+
+		switch value := parts[2]; {
+		case singleQuotedValue.MatchString(value):
+			assignments = append(assignments, Assignment{Name: parts[1], Value: value[1 : len(value)-1]})
+		case doubleQuotedValue.MatchString(value):
+			assignments = append(assignments, Assignment{Name: parts[1], Value: value[1 : len(value)-1], Expand: true})
+		case bareValue.MatchString(value):
+			assignments = append(assignments, Assignment{Name: parts[1], Value: value, Expand: true})
+		default:
+			return nil, ProfileError{File: f, Line: n, Text: line}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read %s\n%w", f, err)
+	}
+
+	return assignments, nil
+}
+
+// Evaluate applies assignments in order to the environment described by lookup, and returns the resulting values of
+// the assigned variables.
+func Evaluate(assignments []Assignment, lookup func(string) (string, bool)) map[string]string {
+	values := map[string]string{}
+
+	get := func(name string) string {
+		if v, ok := values[name]; ok {
+			return v
+		}
+		v, _ := lookup(name)
+		return v
+	}
+
+	for _, a := range assignments {
+		if a.Expand {
+			values[a.Name] = os.Expand(a.Value, get)
+		} else {
+			values[a.Name] = a.Value
+		}
+	}
+
+	return values
+}
+
+// Profile contributes a launch layer containing the helper evaluating the profile scripts of the application before
+// direct processes start.
+type Profile struct {
+	LayerContributor libpak.LayerContributor
+	Logger           bard.Logger
+	Path             string
+	Types            []string
+}
+
+// NewProfile creates a Profile for the process types types, using the helper binary of buildpack.
+func NewProfile(buildpack libcnb.Buildpack, types []string) Profile {
+	expected := map[string]interface{}{"buildpackInfo": buildpack.Info, "types": types}
+
+	return Profile{
+		LayerContributor: libpak.NewLayerContributor("Profile Helper", expected, libcnb.LayerTypes{
+			Launch: true,
+		}),
+		Path:  filepath.Join(buildpack.Path, "bin", "helper"),
+		Types: types,
+	}
+}
+
+func (p Profile) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	p.LayerContributor.Logger = p.Logger
+
+	return p.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+		in, err := os.Open(p.Path)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to open %s\n%w", p.Path, err)
+		}
+		defer in.Close()
+
+		out := filepath.Join(layer.Path, "bin", "helper")
+		if err := sherpa.CopyFile(in, out); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to copy %s to %s\n%w", p.Path, out, err)
+		}
+
+		for _, t := range p.Types {
+			link := layer.Exec.ProcessFilePath(t, ProfileHelper)
+			p.Logger.Bodyf("Evaluating profile scripts for process type %s", t)
+
+			if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to create %s\n%w", filepath.Dir(link), err)
+			}
+			if err := os.Symlink(out, link); err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to link %s to %s\n%w", out, link, err)
+			}
+		}
+
+		return layer, nil
+	})
+}
+
+func (Profile) Name() string {
+	return "profile"
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testProfile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = t.TempDir()
+	})
+
+	it("returns .profile.d scripts in order followed by .profile", func() {
+		Expect(os.MkdirAll(filepath.Join(path, ".profile.d"), 0755)).To(Succeed())
+		for _, f := range []string{".profile", ".profile.d/b.sh", ".profile.d/a.sh", ".profile.d/README"} {
+			Expect(os.WriteFile(filepath.Join(path, f), []byte{}, 0644)).To(Succeed())
+		}
+
+		Expect(procfile.NewProfileScripts(path)).To(Equal([]string{
+			filepath.Join(path, ".profile.d", "a.sh"),
+			filepath.Join(path, ".profile.d", "b.sh"),
+			filepath.Join(path, ".profile"),
+		}))
+	})
+
+	it("returns no scripts without profile", func() {
+		Expect(procfile.NewProfileScripts(path)).To(BeEmpty())
+	})
+
+	it("parses simple assignments", func() {
+		Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte(`# configure the server
+export PATH="$HOME/bin:$PATH"
+export GREETING='hello $USER'
+RAILS_ENV=production
+export RAILS_ENV
+
+export PORT=${PORT}
+`), 0644)).To(Succeed())
+
+		Expect(procfile.NewAssignments(filepath.Join(path, ".profile"))).To(Equal([]procfile.Assignment{
+			{Name: "PATH", Value: "$HOME/bin:$PATH", Expand: true},
+			{Name: "GREETING", Value: "hello $USER"},
+			{Name: "RAILS_ENV", Value: "production", Expand: true},
+			{Name: "PORT", Value: "${PORT}", Expand: true},
+		}))
+	})
+
+	for _, line := range []string{
+		"source .env",
+		"export PATH=$(pwd)/bin:$PATH",
+		"export WORKERS=${WORKERS:-2}",
+		"[ -f .env ] && . .env",
+		"export A=b c",
+		`export A="$(hostname)"`,
+	} {
+		it("returns error with the line requiring a shell: "+line, func() {
+			Expect(os.WriteFile(filepath.Join(path, ".profile"), []byte("export A=b\n"+line+"\n"), 0644)).To(Succeed())
+
+			_, err := procfile.NewAssignments(filepath.Join(path, ".profile"))
+			Expect(err).To(Equal(procfile.ProfileError{File: filepath.Join(path, ".profile"), Line: 2, Text: line}))
+			Expect(err).To(MatchError(fmt.Sprintf("%s:2 requires a shell to evaluate %q, only export NAME=value lines are supported",
+				filepath.Join(path, ".profile"), line)))
+		})
+	}
+
+	it("evaluates assignments in order", func() {
+		env := map[string]string{"HOME": "/home/cnb", "PATH": "/usr/bin"}
+		lookup := func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		}
+
+		Expect(procfile.Evaluate([]procfile.Assignment{
+			{Name: "PATH", Value: "$HOME/bin:$PATH", Expand: true},
+			{Name: "PATH", Value: "/opt/bin:${PATH}", Expand: true},
+			{Name: "GREETING", Value: "hello $USER"},
+		}, lookup)).To(Equal(map[string]string{
+			"PATH":     "/opt/bin:/home/cnb/bin:/usr/bin",
+			"GREETING": "hello $USER",
+		}))
+	})
+
+	it("contributes the helper for process types", func() {
+		buildpack := libcnb.Buildpack{Path: t.TempDir()}
+		Expect(os.MkdirAll(filepath.Join(buildpack.Path, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(buildpack.Path, "bin", "helper"), []byte("test-helper"), 0755)).To(Succeed())
+
+		layers := libcnb.Layers{Path: t.TempDir()}
+		p := procfile.NewProfile(buildpack, []string{"web", "worker"})
+		layer, err := layers.Layer(p.Name())
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = p.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LayerTypes.Launch).To(BeTrue())
+		Expect(filepath.Join(layer.Path, "bin", "helper")).To(BeARegularFile())
+		for _, t := range []string{"web", "worker"} {
+			Expect(os.Readlink(layer.Exec.ProcessFilePath(t, "profile"))).To(Equal(filepath.Join(layer.Path, "bin", "helper")))
+		}
+	})
+}
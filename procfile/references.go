@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// InterpolationAPI is the first Buildpack API whose launcher interpolates $(VAR) references in direct processes.
+const InterpolationAPI = "0.9"
+
+// variableName matches the valid names of environment variables.
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// EnvironmentReferences returns the names of the environment variables referenced by command as $VAR or ${VAR},
+// outside of single quotes and not escaped, each once. The launcher does not interpolate these references in direct
+// processes before Buildpack API 0.9, so they are passed to the process unchanged.
+func EnvironmentReferences(command string) []string {
+	var (
+		names []string
+		quote rune
+	)
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		switch {
+		case c == '\'' && quote != '"':
+			quote = toggle(quote, '\'')
+		case c == '"' && quote != '\'':
+			quote = toggle(quote, '"')
+		case c == '\\' && quote != '\'':
+			i++
+		case c == '$' && quote != '\'':
+			rest := command[i+1:]
+			if strings.HasPrefix(rest, "{") {
+				rest = rest[1:]
+			}
+			if name := variableName.FindString(rest); name != "" {
+				names = appendName(names, name)
+				i += len(name)
+			}
+		}
+	}
+
+	return names
+}
+
+// NewLauncherCommand translates the $VAR and ${VAR} references of command, outside of single quotes, into the $(VAR)
+// syntax that the launcher interpolates in direct processes, and escapes every other dollar sign. It returns the
+// translated command and the names of the referenced variables, each once. Forms that the launcher does not support,
+// such as ${VAR:-default}, special parameters and command substitution, return an error.
+func NewLauncherCommand(command string) (string, []string, error) {
+	var (
+		b           strings.Builder
+		names       []string
+		quote       rune
+		unsupported = func(form string) (string, []string, error) {
+			return "", nil, fmt.Errorf("unsupported reference %s in %q, only $VAR and ${VAR} are interpolated in direct processes", form, command)
+		}
+	)
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+
+		switch {
+		case c == '\'' && quote != '"':
+			quote = toggle(quote, '\'')
+			b.WriteByte(c)
+		case c == '"' && quote != '\'':
+			quote = toggle(quote, '"')
+			b.WriteByte(c)
+		case c == '\\' && quote != '\'' && i+1 < len(command):
+			if command[i+1] == '$' {
+				b.WriteString("$$")
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(command[i+1])
+			}
+			i++
+		case c == '$' && quote != '\'':
+			rest := command[i+1:]
+
+			if name := variableName.FindString(rest); name != "" {
+				b.WriteString(fmt.Sprintf("$(%s)", name))
+				names = appendName(names, name)
+				i += len(name)
+			} else if strings.HasPrefix(rest, "{") {
+				end := strings.IndexByte(rest, '}')
+				if end == -1 {
+					return unsupported("${" + rest[1:])
+				}
+				name := rest[1:end]
+				if variableName.FindString(name) != name {
+					return unsupported(fmt.Sprintf("${%s}", name))
+				}
+				b.WriteString(fmt.Sprintf("$(%s)", name))
+				names = appendName(names, name)
+				i += end + 1
+			} else if strings.HasPrefix(rest, "(") {
+				return unsupported("$(")
+			} else if rest != "" && strings.ContainsRune("0123456789@*#?$!-", rune(rest[0])) {
+				return unsupported("$" + rest[:1])
+			} else {
+				b.WriteString("$$")
+			}
+		case c == '$':
+			b.WriteString("$$")
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), names, nil
+}
+
+// toggle returns an empty quote if current is q, otherwise q.
+func toggle(current rune, q rune) rune {
+	if current == q {
+		return 0
+	}
+	return q
+}
+
+// appendName appends name to names unless it already contains it.
+func appendName(names []string, name string) []string {
+	if slices.Contains(names, name) {
+		return names
+	}
+	return append(names, name)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testReferences(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("returns the referenced variables", func() {
+		Expect(procfile.EnvironmentReferences(`./server --port ${PORT} --host "$HOST" ${TIMEOUT:-5} --listen $PORT`)).
+			To(Equal([]string{"PORT", "HOST", "TIMEOUT"}))
+	})
+
+	for _, command := range []string{
+		"./server --port 8080",
+		`./server '$PORT' \$HOME`,
+		"./server --price 5$ -x",
+		"./server $1 $@",
+	} {
+		it("does not return references for "+command, func() {
+			Expect(procfile.EnvironmentReferences(command)).To(BeEmpty())
+		})
+	}
+
+	for command, expected := range map[string]string{
+		"./server --port $PORT":           "./server --port $(PORT)",
+		`./server --port="${PORT}" $HOME`: `./server --port="$(PORT)" $(HOME)`,
+		`./server '$PORT' \$HOME`:         `./server '$$PORT' $$HOME`,
+		"./server --price 5$ -x":          "./server --price 5$$ -x",
+	} {
+		it("translates "+command, func() {
+			c, _, err := procfile.NewLauncherCommand(command)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(Equal(expected))
+		})
+	}
+
+	it("returns the translated variables", func() {
+		_, names, err := procfile.NewLauncherCommand("./server --port ${PORT} --host $HOST --listen $PORT")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"PORT", "HOST"}))
+	})
+
+	for command, form := range map[string]string{
+		"./server --port ${PORT:-8080}": "${PORT:-8080}",
+		"./server $(hostname)":          "$(",
+		"./server $1":                   "$1",
+		"./server ${PORT":               "${PORT",
+	} {
+		it("fails with unsupported reference "+form, func() {
+			_, _, err := procfile.NewLauncherCommand(command)
+			Expect(err).To(MatchError(ContainSubstring("unsupported reference %s", form)))
+		})
+	}
+}
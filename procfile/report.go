@@ -0,0 +1,208 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+// SourcePlan identifies entries that were passed through the buildpack plan without a known source.
+const SourcePlan = "build plan"
+
+// Source is a Procfile read from a named source.
+type Source struct {
+
+	// Name is the name of the source, for example SourceFile.
+	Name string
+
+	// Procfile is the Procfile read from the source.
+	Procfile Procfile
+}
+
+// Entry is a command contributed to a process type by a source.
+type Entry struct {
+
+	// Source is the name of the source that contributed the command.
+	Source string
+
+	// Command is the command contributed by the source, or Tombstone if it removed the process type.
+	Command string
+}
+
+// ReportEntry describes the source that won a process type and the entries it shadowed.
+type ReportEntry struct {
+	Entry
+
+	// Type is the process type.
+	Type string
+
+	// Shadowed are the entries from lower precedence sources, highest precedence first.
+	Shadowed []Entry
+}
+
+// Removed indicates whether the process type was removed by a Tombstone.
+func (e ReportEntry) Removed() bool {
+	return e.Command == Tombstone
+}
+
+// Report describes how a merged Procfile was assembled from its sources, ordered by process type.
+type Report []ReportEntry
+
+// Merge merges sources, given from lowest to highest precedence, into a single Procfile and reports which source won
+// the command of each process type. Attributes of structured entries are merged individually, higher precedence
+// sources overriding the attributes they declare.
+func Merge(sources ...Source) (Procfile, Report) {
+	p := Procfile{}
+	entries := map[string]*ReportEntry{}
+
+	for _, s := range sources {
+		for k, v := range s.Procfile {
+			c := command(v)
+			if c == Tombstone {
+				delete(p, k)
+			} else if existing, ok := p[k]; ok {
+				p[k] = mergeEntries(existing, v)
+			} else {
+				p[k] = v
+			}
+
+			if c == "" {
+				continue
+			}
+
+			if e, ok := entries[k]; ok {
+				e.Shadowed = append([]Entry{e.Entry}, e.Shadowed...)
+				e.Entry = Entry{Source: s.Name, Command: c}
+			} else {
+				entries[k] = &ReportEntry{Entry: Entry{Source: s.Name, Command: c}, Type: k}
+			}
+		}
+	}
+
+	var r Report
+	for _, e := range entries {
+		r = append(r, *e)
+	}
+	sort.Slice(r, func(i int, j int) bool {
+		return r[i].Type < r[j].Type
+	})
+
+	return p, r
+}
+
+// Match returns the entries of the report for the process types of p, and those that were removed. Process types
+// whose command differs from the reported one, or that are missing from the report, are attributed to SourcePlan.
+func (r Report) Match(p Procfile) Report {
+	var m Report
+	entries := map[string]ReportEntry{}
+	for _, e := range r {
+		if _, ok := p[e.Type]; !ok && e.Removed() {
+			m = append(m, e)
+		}
+		entries[e.Type] = e
+	}
+
+	for k, v := range p {
+		if e, ok := entries[k]; ok && e.Command == command(v) {
+			m = append(m, e)
+		} else {
+			m = append(m, ReportEntry{Entry: Entry{Source: SourcePlan, Command: command(v)}, Type: k})
+		}
+	}
+	sort.Slice(m, func(i int, j int) bool {
+		return m[i].Type < m[j].Type
+	})
+
+	return m
+}
+
+// Redact returns the report with redact applied to every command.
+func (r Report) Redact(redact func(string) string) Report {
+	var redacted Report
+	for _, e := range r {
+		e.Command = redactCommand(e.Command, redact)
+
+		var shadowed []Entry
+		for _, s := range e.Shadowed {
+			s.Command = redactCommand(s.Command, redact)
+			shadowed = append(shadowed, s)
+		}
+		e.Shadowed = shadowed
+
+		redacted = append(redacted, e)
+	}
+	return redacted
+}
+
+// redactCommand applies redact to command if it is not a Tombstone.
+func redactCommand(command string, redact func(string) string) string {
+	if command != Tombstone {
+		return redact(command)
+	}
+	return command
+}
+
+// Log prints the report as a table of process types, their winning source and command, followed by the entries they
+// shadowed, and warns about tombstones without effect.
+func (r Report) Log(logger bard.Logger) {
+	for _, e := range r {
+		for _, s := range e.Shadowed {
+			if s.Command == Tombstone && !e.Removed() {
+				logger.Headerf("Warning: %s removes process type %s without effect, %s takes precedence", s.Source, e.Type, e.Source)
+			}
+		}
+		if e.Removed() && !slices.ContainsFunc(e.Shadowed, func(s Entry) bool { return s.Command != Tombstone }) {
+			logger.Headerf("Warning: %s removes process type %s without effect, no source with lower precedence defines it", e.Source, e.Type)
+		}
+	}
+
+	if len(r) == 0 || !logger.IsBodyEnabled() {
+		return
+	}
+
+	logger.Header("Process types:")
+
+	b := &bytes.Buffer{}
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TYPE\tSOURCE\tCOMMAND")
+	for _, e := range r {
+		if e.Removed() {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t(removed)\n", e.Type, e.Source)
+		} else {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", e.Type, e.Source, e.Command)
+		}
+		for _, s := range e.Shadowed {
+			if s.Command == Tombstone {
+				_, _ = fmt.Fprintf(w, "\t%s\t(removed, shadowed)\n", s.Source)
+			} else {
+				_, _ = fmt.Fprintf(w, "\t%s\t%s (shadowed)\n", s.Source, s.Command)
+			}
+		}
+	}
+	_ = w.Flush()
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		logger.Body(strings.TrimRight(line, " "))
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testReport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		sources []procfile.Source
+	)
+
+	it.Before(func() {
+		sources = []procfile.Source{
+			{Name: "environment", Procfile: procfile.Procfile{"web": "env-command"}},
+			{Name: "file", Procfile: procfile.Procfile{"web": "file-command", "worker": "worker-command"}},
+			{Name: "binding", Procfile: procfile.Procfile{"web": "bind-command"}},
+		}
+	})
+
+	it("merges sources with later sources taking precedence", func() {
+		p, _ := procfile.Merge(sources...)
+
+		Expect(p).To(Equal(procfile.Procfile{"web": "bind-command", "worker": "worker-command"}))
+	})
+
+	it("reports the winning source and shadowed entries", func() {
+		_, r := procfile.Merge(sources...)
+
+		Expect(r).To(Equal(procfile.Report{
+			{
+				Entry: procfile.Entry{Source: "binding", Command: "bind-command"},
+				Type:  "web",
+				Shadowed: []procfile.Entry{
+					{Source: "file", Command: "file-command"},
+					{Source: "environment", Command: "env-command"},
+				},
+			},
+			{
+				Entry: procfile.Entry{Source: "file", Command: "worker-command"},
+				Type:  "worker",
+			},
+		}))
+	})
+
+	it("attributes unknown or changed process types to the build plan", func() {
+		_, r := procfile.Merge(sources...)
+
+		Expect(r.Match(procfile.Procfile{"web": "bind-command", "clock": "clock-command", "worker": "other-command"})).To(Equal(procfile.Report{
+			{Entry: procfile.Entry{Source: "build plan", Command: "clock-command"}, Type: "clock"},
+			r[0],
+			{Entry: procfile.Entry{Source: "build plan", Command: "other-command"}, Type: "worker"},
+		}))
+	})
+
+	it("merges attributes of structured entries and reports their commands", func() {
+		sources = append(sources,
+			procfile.Source{Name: "attributes", Procfile: procfile.Procfile{"web": map[string]interface{}{"direct": true}}},
+		)
+
+		p, r := procfile.Merge(sources...)
+
+		Expect(p["web"]).To(Equal(map[string]interface{}{"command": "bind-command", "direct": true}))
+		Expect(r[0].Entry).To(Equal(procfile.Entry{Source: "binding", Command: "bind-command"}))
+		Expect(r.Match(p)).To(Equal(r))
+	})
+
+	it("removes and reports tombstoned process types", func() {
+		sources = append(sources, procfile.Source{Name: "binding", Procfile: procfile.Procfile{"worker": "~"}})
+
+		p, r := procfile.Merge(sources...)
+
+		Expect(p).To(Equal(procfile.Procfile{"web": "bind-command"}))
+		Expect(r[1].Removed()).To(BeTrue())
+		Expect(r[1].Shadowed).To(Equal([]procfile.Entry{{Source: "file", Command: "worker-command"}}))
+		Expect(r.Match(p)).To(Equal(r))
+	})
+
+	it("logs a table of process types", func() {
+		b := &bytes.Buffer{}
+		_, r := procfile.Merge(sources...)
+
+		r.Log(bard.NewLogger(b))
+
+		Expect(b.String()).To(ContainSubstring("Process types:"))
+		Expect(b.String()).To(MatchRegexp(`web\s+binding\s+bind-command`))
+		Expect(b.String()).To(MatchRegexp(`file\s+file-command \(shadowed\)`))
+		Expect(b.String()).To(MatchRegexp(`environment\s+env-command \(shadowed\)`))
+		Expect(b.String()).To(MatchRegexp(`worker\s+file\s+worker-command`))
+	})
+
+	it("logs the commands of structured entries", func() {
+		b := &bytes.Buffer{}
+		sources = append(sources, procfile.Source{Name: "attributes", Procfile: procfile.Procfile{
+			"web": map[string]interface{}{"command": "attributes-command", "port": int64(8080)},
+		}})
+		_, r := procfile.Merge(sources...)
+
+		r.Log(bard.NewLogger(b))
+
+		Expect(b.String()).To(MatchRegexp(`web\s+attributes\s+attributes-command`))
+		Expect(b.String()).NotTo(ContainSubstring("map["))
+	})
+
+	it("warns about tombstones without effect", func() {
+		b := &bytes.Buffer{}
+		sources = append([]procfile.Source{{Name: "environment", Procfile: procfile.Procfile{"worker": "~", "clock": "~"}}}, sources...)
+		_, r := procfile.Merge(sources...)
+
+		r.Log(bard.NewLogger(b))
+
+		Expect(b.String()).To(ContainSubstring("Warning: environment removes process type worker without effect, file takes precedence"))
+		Expect(b.String()).To(ContainSubstring("Warning: environment removes process type clock without effect, no source with lower precedence defines it"))
+	})
+
+	it("does not warn about tombstones removing process types", func() {
+		b := &bytes.Buffer{}
+		sources = append(sources, procfile.Source{Name: "binding", Procfile: procfile.Procfile{"worker": "~"}})
+		_, r := procfile.Merge(sources...)
+
+		r.Log(bard.NewLogger(b))
+
+		Expect(b.String()).NotTo(ContainSubstring("Warning"))
+	})
+}
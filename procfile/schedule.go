@@ -0,0 +1,51 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+)
+
+// SchedulesLabel is the name of the label describing the schedules of process types.
+const SchedulesLabel = "schedules"
+
+// validateSchedule returns an error if schedule is not a standard cron expression or descriptor such as @hourly.
+func validateSchedule(schedule string) error {
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q\n%w", schedule, err)
+	}
+	return nil
+}
+
+// NewSchedules returns the schedules of the process types of p, by process type.
+func NewSchedules(p Procfile) (map[string]string, error) {
+	schedules := map[string]string{}
+
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+		if d.Schedule != "" {
+			schedules[k] = d.Schedule
+		}
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testSchedule(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	for _, schedule := range []string{"*/5 * * * *", "0 3 * * MON-FRI", "@hourly", "CRON_TZ=Europe/Berlin 0 6 * * *"} {
+		it("returns schedule "+schedule, func() {
+			p := procfile.Procfile{"clock": map[string]interface{}{"command": "clock-command", "schedule": schedule}}
+
+			Expect(procfile.NewSchedules(p)).To(Equal(map[string]string{"clock": schedule}))
+		})
+	}
+
+	for _, schedule := range []string{"every 5 minutes", "* * * *", "61 * * * *", "@fortnightly"} {
+		it("returns error for invalid schedule "+schedule, func() {
+			p := procfile.Procfile{"clock": map[string]interface{}{"command": "clock-command", "schedule": schedule}}
+
+			_, err := procfile.NewSchedules(p)
+			Expect(err).To(MatchError(HavePrefix("invalid process type clock\ninvalid schedule %q\n", schedule)))
+		})
+	}
+
+	it("returns no schedules for unscheduled process types", func() {
+		Expect(procfile.NewSchedules(procfile.Procfile{"web": "web-command"})).To(BeEmpty())
+	})
+}
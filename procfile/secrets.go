@@ -0,0 +1,181 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	SecretsWarn   = "warn"   // SecretsWarn logs a warning for every possible secret found in a command
+	SecretsFail   = "fail"   // SecretsFail fails the build if a possible secret is found in a command
+	SecretsIgnore = "ignore" // SecretsIgnore does not check commands for possible secrets
+
+	// HighEntropy is the rule of random looking strings, which are likely tokens or keys.
+	HighEntropy = "high-entropy string"
+
+	// minimumEntropy is the Shannon entropy in bits per character above which a string of 32 or more characters is
+	// considered random. Shorter strings cannot reach it, so their threshold is scaled down with their length.
+	minimumEntropy = 4.5
+
+	// minimumHexEntropy is the Shannon entropy in bits per character above which a hexadecimal string is considered
+	// random. Hexadecimal strings have at most 4 bits per character.
+	minimumHexEntropy = 3.0
+
+	// minimumHexLength is the length of the shortest hexadecimal string considered a token.
+	minimumHexLength = 32
+
+	// minimumSecretLength is the length of the shortest value considered a secret, shorter values are too likely to
+	// occur in unrelated text to redact them.
+	minimumSecretLength = 4
+)
+
+var (
+	// secretPatterns are the known credential patterns, keyed by rule. The first group of a pattern, if any, is the
+	// secret, otherwise the whole match.
+	secretPatterns = []struct {
+		rule    string
+		pattern *regexp.Regexp
+	}{
+		{"URL with password", regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@]*:([^\s/?#]+)@`)},
+		{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{"AWS secret access key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+		{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+		{"password option", regexp.MustCompile(`(?i)(?:^|\s)--?(?:[a-z0-9]+[-_.])*(?:password|passwd|pwd|secret|token|api[-_]?key)(?:=|\s+)["']?([^\s"']+)`)},
+		{"password assignment", regexp.MustCompile(`(?i)(?:^|\s)(?:[a-z0-9]+[-_.])*(?:password|passwd|secret|token|api[-_]?key)=["']?([^\s"']+)`)},
+	}
+
+	// entropyCandidate matches the strings whose entropy is checked.
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+_-]{20,}={0,2}`)
+
+	// hexString matches hexadecimal strings.
+	hexString = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+	// digestPrefix matches the algorithm prefix of a digest, which is not a secret.
+	digestPrefix = regexp.MustCompile(`(?i)\b(?:md5|sha[0-9]*):$`)
+)
+
+// Secret is a possible secret found in a command.
+type Secret struct {
+
+	// Rule is the rule that matched the secret.
+	Rule string
+
+	// Value is the secret.
+	Value string
+}
+
+// FindSecrets returns the possible secrets in s, either matching a known credential pattern or being a random looking
+// string. Values referencing environment variables, digests and values shorter than four characters are not secrets.
+func FindSecrets(s string) []Secret {
+	var secrets []Secret
+	found := func(v string) bool {
+		for _, secret := range secrets {
+			if strings.Contains(secret.Value, v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range secretPatterns {
+		for _, m := range p.pattern.FindAllStringSubmatch(s, -1) {
+			v := m[len(m)-1]
+			if len(v) < minimumSecretLength || strings.HasPrefix(v, "$") || found(v) {
+				continue
+			}
+			secrets = append(secrets, Secret{Rule: p.rule, Value: v})
+		}
+	}
+
+	for _, m := range entropyCandidate.FindAllStringIndex(s, -1) {
+		v := s[m[0]:m[1]]
+		if !found(v) && strings.ContainsAny(v, "0123456789") && strings.ContainsFunc(v, isLetter) && random(v, s[:m[0]]) {
+			secrets = append(secrets, Secret{Rule: HighEntropy, Value: v})
+		}
+	}
+
+	return secrets
+}
+
+// RedactSecrets replaces the possible secrets in s, longest first.
+func RedactSecrets(s string) string {
+	secrets := FindSecrets(s)
+	sort.Slice(secrets, func(i int, j int) bool {
+		return len(secrets[i].Value) > len(secrets[j].Value)
+	})
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret.Value, Redacted)
+	}
+	return s
+}
+
+// NewSecrets returns the possible secrets in the commands and health check commands of the process types of p, by
+// process type.
+func NewSecrets(p Procfile) (map[string][]Secret, error) {
+	secrets := map[string][]Secret{}
+
+	for k, v := range p {
+		d, err := NewDefinition(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process type %s\n%w", k, err)
+		}
+
+		found := FindSecrets(d.Command)
+		if d.HealthCheck != nil {
+			found = append(found, FindSecrets(d.HealthCheck.Command)...)
+		}
+		if len(found) > 0 {
+			secrets[k] = found
+		}
+	}
+
+	return secrets, nil
+}
+
+// random indicates whether v, following prefix, looks random for its character class: hexadecimal strings that are
+// not digests, or other strings.
+func random(v string, prefix string) bool {
+	if hexString.MatchString(v) {
+		return len(v) >= minimumHexLength && !digestPrefix.MatchString(prefix) && entropy(v) >= minimumHexEntropy
+	}
+	return entropy(v) >= math.Min(minimumEntropy, 0.9*math.Log2(float64(len(v))))
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]float64{}
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var e float64
+	for _, c := range counts {
+		f := c / float64(len(s))
+		e -= f * math.Log2(f)
+	}
+	return e
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
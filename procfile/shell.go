@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak"
+)
+
+// ShellLessDistros are the names of run image distributions known not to provide a shell.
+var ShellLessDistros = []string{"distroless", "scratch"}
+
+// ShellAvailability describes whether the run image provides a shell, and the reason it was decided so.
+type ShellAvailability struct {
+
+	// Available indicates whether the run image provides a shell.
+	Available bool
+
+	// Reason describes how availability was decided.
+	Reason string
+}
+
+// NewShellAvailability decides whether the run image provides a shell. BP_PROCFILE_SHELL_AVAILABLE takes precedence if
+// set, followed by the target distribution described by CNB_TARGET_DISTRO_NAME and CNB_TARGET_DISTRO_VERSION and the
+// deprecated stack ID. Without any of them indicating otherwise, a shell is assumed to be available.
+func NewShellAvailability(stackID string) (ShellAvailability, error) {
+	if s, ok := os.LookupEnv("BP_PROCFILE_SHELL_AVAILABLE"); ok && strings.TrimSpace(s) != "" {
+		available, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return ShellAvailability{}, fmt.Errorf("unable to parse BP_PROCFILE_SHELL_AVAILABLE\n%w", err)
+		}
+		return ShellAvailability{
+			Available: available,
+			Reason:    fmt.Sprintf("as configured by BP_PROCFILE_SHELL_AVAILABLE=%t", available),
+		}, nil
+	}
+
+	distro := strings.TrimSpace(strings.Join([]string{os.Getenv("CNB_TARGET_DISTRO_NAME"), os.Getenv("CNB_TARGET_DISTRO_VERSION")}, " "))
+	if slices.Contains(ShellLessDistros, strings.ToLower(os.Getenv("CNB_TARGET_DISTRO_NAME"))) {
+		return ShellAvailability{Reason: fmt.Sprintf("the run image distribution %s does not provide a shell", distro)}, nil
+	}
+
+	if libpak.IsTinyStack(stackID) || libpak.IsStaticStack(stackID) {
+		return ShellAvailability{Reason: fmt.Sprintf("the stack %s does not provide a shell", stackID)}, nil
+	}
+
+	switch {
+	case libpak.IsShellPresentOnStack(stackID):
+		return ShellAvailability{Available: true, Reason: fmt.Sprintf("the stack %s provides a shell", stackID)}, nil
+	case distro != "":
+		return ShellAvailability{Available: true, Reason: fmt.Sprintf("the run image distribution %s is assumed to provide a shell", distro)}, nil
+	default:
+		return ShellAvailability{Available: true, Reason: "no target metadata indicates that the run image lacks a shell"}, nil
+	}
+}
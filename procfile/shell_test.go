@@ -0,0 +1,99 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testShell(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("assumes a shell without metadata", func() {
+		Expect(procfile.NewShellAvailability("")).To(Equal(procfile.ShellAvailability{
+			Available: true,
+			Reason:    "no target metadata indicates that the run image lacks a shell",
+		}))
+	})
+
+	it("uses a shell on a full stack", func() {
+		Expect(procfile.NewShellAvailability(libpak.JammyStackID)).To(Equal(procfile.ShellAvailability{
+			Available: true,
+			Reason:    "the stack io.buildpacks.stacks.jammy provides a shell",
+		}))
+	})
+
+	it("detects stacks without a shell", func() {
+		Expect(procfile.NewShellAvailability(libpak.NobleStaticStackID)).To(Equal(procfile.ShellAvailability{
+			Reason: "the stack io.buildpacks.stacks.noble.static does not provide a shell",
+		}))
+	})
+
+	it("detects distributions without a shell from target metadata", func() {
+		t.Setenv("CNB_TARGET_DISTRO_NAME", "distroless")
+		t.Setenv("CNB_TARGET_DISTRO_VERSION", "12")
+
+		Expect(procfile.NewShellAvailability(libpak.JammyStackID)).To(Equal(procfile.ShellAvailability{
+			Reason: "the run image distribution distroless 12 does not provide a shell",
+		}))
+	})
+
+	it("assumes a shell for other distributions", func() {
+		t.Setenv("CNB_TARGET_DISTRO_NAME", "ubuntu")
+		t.Setenv("CNB_TARGET_DISTRO_VERSION", "24.04")
+
+		Expect(procfile.NewShellAvailability("")).To(Equal(procfile.ShellAvailability{
+			Available: true,
+			Reason:    "the run image distribution ubuntu 24.04 is assumed to provide a shell",
+		}))
+	})
+
+	context("given BP_PROCFILE_SHELL_AVAILABLE", func() {
+		it("overrides the stack", func() {
+			t.Setenv("BP_PROCFILE_SHELL_AVAILABLE", "true")
+
+			Expect(procfile.NewShellAvailability(libpak.JammyTinyStackID)).To(Equal(procfile.ShellAvailability{
+				Available: true,
+				Reason:    "as configured by BP_PROCFILE_SHELL_AVAILABLE=true",
+			}))
+		})
+
+		it("overrides target metadata", func() {
+			t.Setenv("BP_PROCFILE_SHELL_AVAILABLE", "false")
+			t.Setenv("CNB_TARGET_DISTRO_NAME", "ubuntu")
+
+			Expect(procfile.NewShellAvailability(libpak.JammyStackID)).To(Equal(procfile.ShellAvailability{
+				Reason: "as configured by BP_PROCFILE_SHELL_AVAILABLE=false",
+			}))
+		})
+
+		it("fails with an invalid value", func() {
+			t.Setenv("BP_PROCFILE_SHELL_AVAILABLE", "maybe")
+
+			_, err := procfile.NewShellAvailability("")
+			Expect(err).To(MatchError(ContainSubstring("unable to parse BP_PROCFILE_SHELL_AVAILABLE")))
+		})
+	})
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+
+	"github.com/paketo-buildpacks/procfile/v5/supervisor"
+)
+
+// SupervisorProcessType is the type of the process that starts all supervised processes.
+const SupervisorProcessType = "all"
+
+// Supervisor contributes a launch layer containing the supervisor binary and the processes it starts.
+type Supervisor struct {
+	LayerContributor libpak.LayerContributor
+	Logger           bard.Logger
+	Path             string
+	Processes        []libcnb.Process
+}
+
+// NewSupervisor creates a Supervisor for processes, using the supervisor binary of buildpack.
+func NewSupervisor(buildpack libcnb.Buildpack, processes []libcnb.Process) Supervisor {
+	expected := map[string]interface{}{"buildpackInfo": buildpack.Info, "processes": processes}
+
+	return Supervisor{
+		LayerContributor: libpak.NewLayerContributor("Process Supervisor", expected, libcnb.LayerTypes{
+			Launch: true,
+		}),
+		Path:      filepath.Join(buildpack.Path, "bin", "supervisor"),
+		Processes: processes,
+	}
+}
+
+// Process returns the process starting all supervised processes from layers.
+func (s Supervisor) Process(layers libcnb.Layers) libcnb.Process {
+	path := filepath.Join(layers.Path, s.Name())
+
+	return libcnb.Process{
+		Type:      SupervisorProcessType,
+		Command:   filepath.Join(path, "bin", "supervisor"),
+		Arguments: []string{filepath.Join(path, "processes.toml")},
+		Direct:    true,
+	}
+}
+
+func (s Supervisor) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	s.LayerContributor.Logger = s.Logger
+
+	return s.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+		in, err := os.Open(s.Path)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to open %s\n%w", s.Path, err)
+		}
+		defer in.Close()
+
+		out := filepath.Join(layer.Path, "bin", "supervisor")
+		if err := sherpa.CopyFile(in, out); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to copy %s to %s\n%w", s.Path, out, err)
+		}
+
+		file := filepath.Join(layer.Path, "processes.toml")
+		f, err := os.Create(file)
+		if err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to create %s\n%w", file, err)
+		}
+		defer f.Close()
+
+		if err := toml.NewEncoder(f).Encode(supervisor.Configuration{Processes: s.Processes}); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to write %s\n%w", file, err)
+		}
+
+		for _, p := range s.Processes {
+			s.Logger.Bodyf("Supervising process type %s", p.Type)
+		}
+
+		return layer, nil
+	})
+}
+
+func (Supervisor) Name() string {
+	return "supervisor"
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+	"github.com/paketo-buildpacks/procfile/v5/supervisor"
+)
+
+func testSupervisor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx       libcnb.BuildContext
+		processes []libcnb.Process
+	)
+
+	it.Before(func() {
+		ctx.Buildpack.Path = t.TempDir()
+		ctx.Layers.Path = t.TempDir()
+
+		Expect(os.MkdirAll(filepath.Join(ctx.Buildpack.Path, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Buildpack.Path, "bin", "supervisor"), []byte("test-supervisor"), 0755)).To(Succeed())
+
+		processes = []libcnb.Process{
+			{Type: "web", Command: "test-command"},
+			{Type: "worker", Command: "test-worker", Arguments: []string{"test-argument"}, Direct: true},
+		}
+	})
+
+	it("contributes the supervisor and its configuration", func() {
+		s := procfile.NewSupervisor(ctx.Buildpack, processes)
+		layer, err := ctx.Layers.Layer(s.Name())
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = s.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.LayerTypes.Launch).To(BeTrue())
+		Expect(filepath.Join(layer.Path, "bin", "supervisor")).To(BeARegularFile())
+		Expect(supervisor.NewConfigurationFromPath(filepath.Join(layer.Path, "processes.toml"))).To(Equal(supervisor.Configuration{
+			Processes: processes,
+		}))
+	})
+
+	it("returns the process starting the supervisor", func() {
+		s := procfile.NewSupervisor(ctx.Buildpack, processes)
+
+		Expect(s.Process(ctx.Layers)).To(Equal(libcnb.Process{
+			Type:      "all",
+			Command:   filepath.Join(ctx.Layers.Path, "supervisor", "bin", "supervisor"),
+			Arguments: []string{filepath.Join(ctx.Layers.Path, "supervisor", "processes.toml")},
+			Direct:    true,
+		}))
+	})
+}
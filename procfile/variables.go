@@ -0,0 +1,176 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/bindings"
+)
+
+const (
+	VariablesBindingType = "ProcfileVariables" // VariablesBindingType is used to resolve bindings containing values of placeholders
+	Redacted             = "[REDACTED]"        // Redacted replaces values read from bindings when logged
+)
+
+// minimumRedacted is the length below which values are not redacted, as replacing them would mangle unrelated text.
+const minimumRedacted = 4
+
+// placeholder matches the ${BP_NAME} and ${BP_NAME:-default} placeholders of commands.
+var placeholder = regexp.MustCompile(`\$\{(BP_[A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Variables are the values of the placeholders substituted in Procfile commands at build time.
+type Variables struct {
+
+	// Values are the values of placeholders by name.
+	Values map[string]string
+
+	// Sensitive are the names of values read from bindings, which are redacted when logged.
+	Sensitive map[string]bool
+}
+
+// NewVariables creates Variables from the BP_ prefixed build environment and the bindings of type ProcfileVariables,
+// which take precedence.
+func NewVariables(binds libcnb.Bindings) Variables {
+	v := Variables{Values: map[string]string{}, Sensitive: map[string]bool{}}
+
+	for _, e := range os.Environ() {
+		if k, value, ok := strings.Cut(e, "="); ok && strings.HasPrefix(k, "BP_") {
+			v.Values[k] = value
+		}
+	}
+
+	for _, b := range bindings.Resolve(binds, bindings.OfType(VariablesBindingType)) {
+		for k, value := range b.Secret {
+			v.Values[k] = value
+			v.Sensitive[k] = true
+		}
+	}
+
+	return v
+}
+
+// Substitute replaces the placeholders of command with their values, or their defaults if they have none or an empty
+// one. It returns the substituted command and the names of the substituted placeholders. Placeholders without value or
+// default return an error.
+func (v Variables) Substitute(command string) (string, []string, error) {
+	var (
+		names      []string
+		unresolved []string
+	)
+
+	s := placeholder.ReplaceAllStringFunc(command, func(m string) string {
+		parts := placeholder.FindStringSubmatch(m)
+		names = append(names, parts[1])
+
+		if value, ok := v.Values[parts[1]]; ok && (value != "" || parts[2] == "") {
+			return value
+		} else if parts[2] != "" {
+			return parts[3]
+		}
+
+		unresolved = append(unresolved, parts[1])
+		return m
+	})
+
+	if len(unresolved) > 0 {
+		return "", nil, fmt.Errorf("unresolved placeholders %s", strings.Join(unresolved, ", "))
+	}
+
+	return s, names, nil
+}
+
+// SubstituteSources substitutes the placeholders of the commands of sources, and logs the values used.
+func (v Variables) SubstituteSources(sources []Source, logger bard.Logger) ([]Source, error) {
+	used := map[string]bool{}
+
+	var substituted []Source
+	for _, s := range sources {
+		p := Procfile{}
+		for k, e := range s.Procfile {
+			c := command(e)
+			if c == "" || c == Tombstone {
+				p[k] = e
+				continue
+			}
+
+			c, names, err := v.Substitute(c)
+			if err != nil {
+				return nil, fmt.Errorf("unable to substitute process type %s from %s\n%w", k, s.Name, err)
+			}
+			for _, n := range names {
+				used[n] = true
+			}
+
+			if m, ok := e.(map[string]interface{}); ok {
+				m = maps.Clone(m)
+				m["command"] = c
+				p[k] = m
+			} else {
+				p[k] = c
+			}
+		}
+		substituted = append(substituted, Source{Name: s.Name, Procfile: p})
+	}
+
+	if len(used) > 0 {
+		var names []string
+		for n := range used {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		logger.Header("Substituting placeholders of Procfile commands:")
+		for _, n := range names {
+			if value, ok := v.Values[n]; !ok || value == "" {
+				logger.Bodyf("%s: default", n)
+			} else if v.Sensitive[n] {
+				logger.Bodyf("%s=%s", n, Redacted)
+			} else {
+				logger.Bodyf("%s=%s", n, RedactSecrets(value))
+			}
+		}
+	}
+
+	return substituted, nil
+}
+
+// Redact replaces the values read from bindings in s, longest first. Values shorter than four characters are not
+// replaced.
+func (v Variables) Redact(s string) string {
+	var values []string
+	for n := range v.Sensitive {
+		if value := v.Values[n]; len(value) >= minimumRedacted {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i int, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+	return s
+}
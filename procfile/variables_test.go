@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package procfile_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/procfile"
+)
+
+func testVariables(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		bindings = libcnb.Bindings{
+			{Name: "variables", Type: "ProcfileVariables", Secret: map[string]string{"BP_TOKEN": "s3cr3t", "BP_ENV": "staging"}},
+		}
+	)
+
+	it("reads BP_ prefixed environment variables and bindings", func() {
+		t.Setenv("BP_ENV", "production")
+		t.Setenv("BP_REGION", "eu")
+		t.Setenv("OTHER", "value")
+
+		v := procfile.NewVariables(bindings)
+		Expect(v.Values).To(HaveKeyWithValue("BP_ENV", "staging"))
+		Expect(v.Values).To(HaveKeyWithValue("BP_REGION", "eu"))
+		Expect(v.Values).To(HaveKeyWithValue("BP_TOKEN", "s3cr3t"))
+		Expect(v.Values).NotTo(HaveKey("OTHER"))
+		Expect(v.Sensitive).To(Equal(map[string]bool{"BP_ENV": true, "BP_TOKEN": true}))
+	})
+
+	it("substitutes placeholders with values and defaults", func() {
+		v := procfile.Variables{Values: map[string]string{"BP_ENV": "production"}}
+
+		c, names, err := v.Substitute("./server --env ${BP_ENV} --workers ${BP_WORKERS:-4} --port ${PORT}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c).To(Equal("./server --env production --workers 4 --port ${PORT}"))
+		Expect(names).To(Equal([]string{"BP_ENV", "BP_WORKERS"}))
+	})
+
+	it("substitutes defaults for empty values", func() {
+		v := procfile.Variables{Values: map[string]string{"BP_ENV": "", "BP_REGION": ""}}
+
+		c, _, err := v.Substitute("./server --env ${BP_ENV:-production} --region ${BP_REGION}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c).To(Equal("./server --env production --region "))
+	})
+
+	it("returns error for unresolved placeholders", func() {
+		_, _, err := procfile.Variables{}.Substitute("./server --env ${BP_ENV} ${BP_REGION}")
+		Expect(err).To(MatchError("unresolved placeholders BP_ENV, BP_REGION"))
+	})
+
+	it("substitutes sources and logs values with binding values redacted", func() {
+		t.Setenv("BP_REGION", "eu")
+		b := &bytes.Buffer{}
+
+		sources, err := procfile.NewVariables(bindings).SubstituteSources([]procfile.Source{
+			{Name: "file", Procfile: procfile.Procfile{
+				"web":    "./server --token ${BP_TOKEN} --region ${BP_REGION} --size ${BP_SIZE:-small}",
+				"worker": map[string]interface{}{"command": "./worker ${BP_REGION}", "direct": true},
+				"clock":  "~",
+			}},
+		}, bard.NewLogger(b))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sources).To(Equal([]procfile.Source{
+			{Name: "file", Procfile: procfile.Procfile{
+				"web":    "./server --token s3cr3t --region eu --size small",
+				"worker": map[string]interface{}{"command": "./worker eu", "direct": true},
+				"clock":  "~",
+			}},
+		}))
+		Expect(b.String()).To(ContainSubstring("BP_REGION=eu"))
+		Expect(b.String()).To(ContainSubstring("BP_SIZE: default"))
+		Expect(b.String()).To(ContainSubstring("BP_TOKEN=[REDACTED]"))
+		Expect(b.String()).NotTo(ContainSubstring("s3cr3t"))
+	})
+
+	it("returns error with the process type and source of unresolved placeholders", func() {
+		_, err := procfile.Variables{}.SubstituteSources([]procfile.Source{
+			{Name: "file", Procfile: procfile.Procfile{"web": "./server ${BP_ENV}"}},
+		}, bard.NewLogger(&bytes.Buffer{}))
+		Expect(err).To(MatchError("unable to substitute process type web from file\nunresolved placeholders BP_ENV"))
+	})
+
+	it("redacts binding values", func() {
+		v := procfile.NewVariables(bindings)
+		Expect(v.Redact("./server --token s3cr3t --env staging")).To(Equal("./server --token [REDACTED] --env [REDACTED]"))
+	})
+
+	it("does not redact short binding values", func() {
+		v := procfile.NewVariables(libcnb.Bindings{
+			{Name: "variables", Type: "ProcfileVariables", Secret: map[string]string{"BP_TOKEN": "s3cr3t", "BP_DEBUG": "1"}},
+		})
+		Expect(v.Redact("./server --token s3cr3t --port 1000")).To(Equal("./server --token [REDACTED] --port 1000"))
+	})
+}
@@ -4,13 +4,17 @@ set -euo pipefail
 GOMOD=$(head -1 go.mod | awk '{print $2}')
 GOOS="linux" GOARCH="amd64" go build -ldflags='-s -w' -o "linux/amd64/bin/main" "$GOMOD/cmd/main"
 GOOS="linux" GOARCH="arm64" go build -ldflags='-s -w' -o "linux/arm64/bin/main" "$GOMOD/cmd/main"
+GOOS="linux" GOARCH="amd64" go build -ldflags='-s -w' -o "linux/amd64/bin/supervisor" "$GOMOD/cmd/supervisor"
+GOOS="linux" GOARCH="arm64" go build -ldflags='-s -w' -o "linux/arm64/bin/supervisor" "$GOMOD/cmd/supervisor"
+GOOS="linux" GOARCH="amd64" go build -ldflags='-s -w' -o "linux/amd64/bin/helper" "$GOMOD/cmd/helper"
+GOOS="linux" GOARCH="arm64" go build -ldflags='-s -w' -o "linux/arm64/bin/helper" "$GOMOD/cmd/helper"
 
 if [ "${STRIP:-false}" != "false" ]; then
-  strip linux/amd64/bin/main linux/arm64/bin/main
+  strip linux/amd64/bin/main linux/arm64/bin/main linux/amd64/bin/supervisor linux/arm64/bin/supervisor linux/amd64/bin/helper linux/arm64/bin/helper
 fi
 
 if [ "${COMPRESS:-none}" != "none" ]; then
-  $COMPRESS linux/amd64/bin/main linux/arm64/bin/main
+  $COMPRESS linux/amd64/bin/main linux/arm64/bin/main linux/amd64/bin/supervisor linux/arm64/bin/supervisor linux/amd64/bin/helper linux/arm64/bin/helper
 fi
 ln -fs main linux/amd64/bin/build
 ln -fs main linux/amd64/bin/detect
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package supervisor
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes complete lines prefixed with the name of a process, holding back partial lines until they are
+// completed or flushed. Writers sharing a mutex interleave whole lines.
+type prefixWriter struct {
+	prefix string
+	writer io.Writer
+	mutex  *sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (w *prefixWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buffer.Write(b)
+	for {
+		i := bytes.IndexByte(w.buffer.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+
+		line := w.buffer.Next(i + 1)
+		if _, err := io.WriteString(w.writer, w.prefix+string(line)); err != nil {
+			return len(b), err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush writes a held back partial line.
+func (w *prefixWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.buffer.Len() > 0 {
+		_, _ = io.WriteString(w.writer, w.prefix+w.buffer.String()+"\n")
+		w.buffer.Reset()
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libcnb"
+)
+
+const (
+	DefaultShell   = "bash"           // DefaultShell is the shell the launcher executes processes that are not direct with
+	DefaultGrace   = 1 * time.Second  // DefaultGrace is the time processes are given to exit on their own after one exited
+	DefaultTimeout = 10 * time.Second // DefaultTimeout is the time processes are given to exit after being terminated
+)
+
+// colors are the ANSI foreground colors cycled through for the prefixes of processes.
+var colors = []int{36, 33, 32, 35, 34, 31}
+
+// Configuration is the list of processes started by a Supervisor, as stored in a launch layer.
+type Configuration struct {
+
+	// Processes are the processes to start.
+	Processes []libcnb.Process `toml:"processes"`
+}
+
+// NewConfigurationFromPath reads a Configuration from path.
+func NewConfigurationFromPath(path string) (Configuration, error) {
+	var c Configuration
+	if _, err := toml.DecodeFile(path, &c); err != nil {
+		return Configuration{}, fmt.Errorf("unable to decode %s\n%w", path, err)
+	}
+	return c, nil
+}
+
+// Supervisor starts processes, prefixes and interleaves their output, forwards signals to them and stops all of them
+// when any of them exits.
+type Supervisor struct {
+
+	// Processes are the processes to start.
+	Processes []libcnb.Process
+
+	// Shell is the shell used to start processes that are not direct.
+	Shell string
+
+	// Stdout is the writer the prefixed standard output of processes is written to.
+	Stdout io.Writer
+
+	// Stderr is the writer the prefixed standard error of processes is written to.
+	Stderr io.Writer
+
+	// Grace is the time processes are given to exit on their own after one of them exited, before they are terminated,
+	// so that short-lived processes are not terminated before they write their output.
+	Grace time.Duration
+
+	// Timeout is the time processes are given to exit after being terminated, before they are killed.
+	Timeout time.Duration
+
+	// Environment are additional environment variables of processes in NAME=value form, keyed by process type.
+	Environment map[string][]string
+
+	// Colors indicates whether the prefixes of processes are colored.
+	Colors bool
+}
+
+// NewSupervisor creates a Supervisor for the processes of configuration, writing to os.Stdout and os.Stderr.
+func NewSupervisor(configuration Configuration) Supervisor {
+	return Supervisor{
+		Processes: configuration.Processes,
+		Shell:     DefaultShell,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+		Grace:     DefaultGrace,
+		Timeout:   DefaultTimeout,
+	}
+}
+
+type exit struct {
+	process libcnb.Process
+	code    int
+}
+
+// Run starts all processes and forwards signals received on signals to them. The $(VAR) references of direct processes
+// are interpolated like the launcher does. When any process exits, the remaining ones are given Grace to exit on their
+// own before they are terminated, and Run returns the exit code of the first process that exited. The output of
+// processes is written completely, as their pipes are drained until they exit.
+func (s Supervisor) Run(signals <-chan os.Signal) (int, error) {
+	if len(s.Processes) == 0 {
+		return 0, fmt.Errorf("no processes to supervise")
+	}
+
+	width := 0
+	for _, p := range s.Processes {
+		width = max(width, len(p.Type))
+	}
+
+	var (
+		mutex   = &sync.Mutex{}
+		exits   = make(chan exit, len(s.Processes))
+		running = map[string]*exec.Cmd{}
+		writers []*prefixWriter
+	)
+
+	for i, p := range s.Processes {
+		prefix := fmt.Sprintf("%-*s | ", width, p.Type)
+		if s.Colors {
+			prefix = fmt.Sprintf("\x1b[%dm%s\x1b[0m", colors[i%len(colors)], prefix)
+		}
+
+		stdout := &prefixWriter{prefix: prefix, writer: s.Stdout, mutex: mutex}
+		stderr := &prefixWriter{prefix: prefix, writer: s.Stderr, mutex: mutex}
+		writers = append(writers, stdout, stderr)
+
+		env := append(os.Environ(), s.Environment[p.Type]...)
+		cmd := s.command(p, env)
+		cmd.Env = env
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+		if err := cmd.Start(); err != nil {
+			s.signal(running, syscall.SIGKILL)
+			return 0, fmt.Errorf("unable to start process %s\n%w", p.Type, err)
+		}
+		running[p.Type] = cmd
+
+		go func(p libcnb.Process, cmd *exec.Cmd) {
+			exits <- exit{process: p, code: exitCode(cmd.Wait())}
+		}(p, cmd)
+	}
+
+	var (
+		first   *exit
+		grace   <-chan time.Time
+		timeout <-chan time.Time
+	)
+
+	for len(running) > 0 {
+		select {
+		case sig := <-signals:
+			s.signal(running, sig)
+		case e := <-exits:
+			delete(running, e.process.Type)
+			if first == nil {
+				first = &e
+				mutex.Lock()
+				_, _ = fmt.Fprintf(s.Stderr, "%s exited with code %d, stopping all processes\n", e.process.Type, e.code)
+				mutex.Unlock()
+				grace = time.After(s.Grace)
+			}
+		case <-grace:
+			grace = nil
+			s.signal(running, syscall.SIGTERM)
+			timeout = time.After(s.Timeout)
+		case <-timeout:
+			s.signal(running, syscall.SIGKILL)
+		}
+	}
+
+	for _, w := range writers {
+		w.Flush()
+	}
+
+	return first.code, nil
+}
+
+func (s Supervisor) command(process libcnb.Process, env []string) *exec.Cmd {
+	if process.Direct {
+		lookup := func(name string) string {
+			for i := len(env) - 1; i >= 0; i-- {
+				if k, v, ok := strings.Cut(env[i], "="); ok && k == name {
+					return v
+				}
+			}
+			return ""
+		}
+
+		var args []string
+		for _, a := range process.Arguments {
+			args = append(args, interpolate(a, lookup))
+		}
+
+		cmd := exec.Command(interpolate(process.Command, lookup), args...)
+		cmd.Dir = process.WorkingDirectory
+		return cmd
+	}
+
+	cmd := exec.Command(s.Shell, append([]string{"-c", process.Command, process.Type}, process.Arguments...)...)
+	cmd.Dir = process.WorkingDirectory
+	return cmd
+}
+
+// interpolate replaces the $(VAR) references of s with the values returned by lookup, and unescapes $$, like the
+// launcher does for direct processes.
+func interpolate(s string, lookup func(string) string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+		} else if end := strings.IndexByte(s[i+1:], ')'); s[i+1] == '(' && end != -1 {
+			b.WriteString(lookup(s[i+2 : i+1+end]))
+			i += 1 + end
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+func (Supervisor) signal(running map[string]*exec.Cmd, sig os.Signal) {
+	for _, cmd := range running {
+		if s, ok := sig.(syscall.Signal); ok {
+			// signal the process group so that children of shell processes receive it too
+			_ = syscall.Kill(-cmd.Process.Pid, s)
+		} else {
+			_ = cmd.Process.Signal(sig)
+		}
+	}
+}
+
+func exitCode(err error) int {
+	var e *exec.ExitError
+	if !errors.As(err, &e) {
+		if err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	if status, ok := e.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return e.ExitCode()
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package supervisor_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/procfile/v5/supervisor"
+)
+
+func testSupervisor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stdout, stderr *bytes.Buffer
+		s              supervisor.Supervisor
+	)
+
+	it.Before(func() {
+		stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+		s = supervisor.Supervisor{Shell: "/bin/sh", Stdout: stdout, Stderr: stderr, Grace: supervisor.DefaultGrace, Timeout: 5 * time.Second}
+	})
+
+	it("reads a configuration", func() {
+		path := filepath.Join(t.TempDir(), "processes.toml")
+		Expect(os.WriteFile(path, []byte(`[[processes]]
+type = "web"
+command = "test-command"
+args = ["test-argument"]
+direct = true
+`), 0644)).To(Succeed())
+
+		Expect(supervisor.NewConfigurationFromPath(path)).To(Equal(supervisor.Configuration{
+			Processes: []libcnb.Process{
+				{Type: "web", Command: "test-command", Arguments: []string{"test-argument"}, Direct: true},
+			},
+		}))
+	})
+
+	it("prefixes the output of processes", func() {
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "echo web-output; echo web-error >&2"},
+			{Type: "worker", Command: "/bin/echo", Arguments: []string{"worker-output"}, Direct: true},
+		}
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("web    | web-output\n"))
+		Expect(stdout.String()).To(ContainSubstring("worker | worker-output\n"))
+		Expect(stderr.String()).To(ContainSubstring("web    | web-error\n"))
+	})
+
+	it("adds environment variables of processes", func() {
+		s.Environment = map[string][]string{"web": {"PORT=5000"}}
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "echo port=$PORT"},
+		}
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(Equal("web | port=5000\n"))
+	})
+
+	it("runs processes that are not direct with bash by default", func() {
+		s = supervisor.NewSupervisor(supervisor.Configuration{Processes: []libcnb.Process{
+			{Type: "web", Command: "[[ -n bash ]] && words=(web output) && echo ${words[@]}"},
+		}})
+		s.Stdout, s.Stderr = stdout, stderr
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(Equal("web | web output\n"))
+	})
+
+	it("interpolates environment variables of direct processes", func() {
+		s.Environment = map[string][]string{"web": {"PORT=5000"}}
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "/bin/echo", Arguments: []string{"port=$(PORT)", "price=5$$"}, Direct: true},
+		}
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(Equal("web | port=5000 price=5$\n"))
+	})
+
+	it("colors prefixes", func() {
+		s.Colors = true
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "echo web-output"},
+		}
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(Equal("\x1b[36mweb | \x1b[0mweb-output\n"))
+	})
+
+	it("stops all processes and returns the exit code of the first one that exits", func() {
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "sleep 60"},
+			{Type: "worker", Command: "exit 3"},
+		}
+
+		start := time.Now()
+		Expect(s.Run(nil)).To(Equal(3))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		Expect(stderr.String()).To(ContainSubstring("worker exited with code 3, stopping all processes"))
+	})
+
+	it("does not terminate short-lived processes before they write their output", func() {
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "/bin/true", Direct: true},
+			{Type: "worker", Command: "sleep 0.1; echo worker-output"},
+		}
+
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(stdout.String()).To(Equal("worker | worker-output\n"))
+	})
+
+	it("kills processes that do not exit after being terminated", func() {
+		s.Grace = 100 * time.Millisecond
+		s.Timeout = 100 * time.Millisecond
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "trap '' TERM; sleep 60"},
+			{Type: "worker", Command: "sleep 0.2"},
+		}
+
+		start := time.Now()
+		Expect(s.Run(nil)).To(Equal(0))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	it("forwards signals to processes", func() {
+		s.Processes = []libcnb.Process{
+			{Type: "web", Command: "trap 'echo terminated; exit 0' TERM; while true; do sleep 0.1; done"},
+		}
+
+		signals := make(chan os.Signal, 1)
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			signals <- syscall.SIGTERM
+		}()
+
+		Expect(s.Run(signals)).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("web | terminated\n"))
+	})
+
+	it("fails without processes", func() {
+		_, err := s.Run(nil)
+		Expect(err).To(MatchError("no processes to supervise"))
+	})
+}